@@ -0,0 +1,113 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// reMissingEnv is a regex intended to match template execution errors that look
+// like `template: comment:3:7: executing "comment" at <.Env.FOO>: map has no
+// entry for key "FOO"`, and capture the template location and the name of the
+// missing variable.
+var reMissingEnv = regexp.MustCompile(`^template: (.*?): executing ".*?" at <\.Env\.([^>.]+)>: map has no entry for key`)
+
+// Allowlist restricts the environment exposed to templates to only those
+// variables that were explicitly allowed.
+type Allowlist struct {
+	// patterns is the list of patterns matching the names of environment
+	// variables to allow.
+	patterns []string
+}
+
+// NewAllowlist builds an Allowlist that allows the given list of patterns.
+// Patterns use the syntax of path.Match, and are matched case-insensitively.
+func NewAllowlist(patterns []string) (*Allowlist, error) {
+	all := make([]string, len(patterns))
+	for index, pattern := range patterns {
+		all[index] = strings.ToUpper(pattern)
+		if _, err := path.Match(all[index], ""); err != nil {
+			return nil, fmt.Errorf("malformed allow pattern %q", pattern)
+		}
+	}
+
+	return &Allowlist{patterns: all}, nil
+}
+
+// ReadAllowlistFile reads a list of patterns from the named file. Patterns are
+// listed one per line. Blank lines, and lines starting with "#", are ignored.
+func ReadAllowlistFile(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+
+	return patterns, scanner.Err()
+}
+
+// Allowed returns true if the named environment variable is allowed. A nil
+// Allowlist allows every variable.
+func (a *Allowlist) Allowed(name string) bool {
+	if a == nil {
+		return true
+	}
+
+	name = strings.ToUpper(name)
+	for _, pattern := range a.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns a copy of the given environment, containing only the allowed
+// variables.
+func (a *Allowlist) Filter(env map[string]string) map[string]string {
+	filtered := make(map[string]string)
+	for name, value := range env {
+		if a.Allowed(name) {
+			filtered[name] = value
+		}
+	}
+	return filtered
+}
+
+// Explain rewrites a template execution error caused by referencing a missing
+// environment variable into one that names the variable, and says whether it
+// was disallowed or simply not set. All other errors are returned unchanged. A
+// nil Allowlist may be used to explain errors raised in strict mode.
+func (a *Allowlist) Explain(err error, env map[string]string) error {
+	if err == nil {
+		return nil
+	}
+
+	res := reMissingEnv.FindStringSubmatch(err.Error())
+	if res == nil {
+		return err
+	}
+
+	location, name := res[1], res[2]
+	if _, found := env[name]; found && !a.Allowed(name) {
+		return fmt.Errorf("template: %s: environment variable %q is not allowed by -env-allow", location, name)
+	}
+	return fmt.Errorf("template: %s: environment variable %q is not set", location, name)
+}
@@ -0,0 +1,149 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowlist(t *testing.T) {
+	env := map[string]string{
+		"BUILD_NUMBER":  "42",
+		"CIRCLE_BRANCH": "feature",
+		"CIRCLE_SHA1":   "c0ffee",
+		"HOME":          "/root",
+		"STATUS":        "passed",
+	}
+
+	allowlist, err := NewAllowlist([]string{"circle_*", "STATUS", "MISSING"})
+	assert.Nil(t, err)
+
+	filtered := allowlist.Filter(env)
+	assert.Equal(t, map[string]string{
+		"CIRCLE_BRANCH": "feature",
+		"CIRCLE_SHA1":   "c0ffee",
+		"STATUS":        "passed",
+	}, filtered)
+
+	tests := []struct {
+		title    string
+		body     string
+		expected string
+		error    string
+	}{
+		{
+			title:    "allowed variables",
+			body:     "Status is {{.Env.STATUS}} on {{.Env.CIRCLE_BRANCH}}.",
+			expected: "Status is passed on feature.",
+		},
+		{
+			title: "disallowed variable",
+			body:  "Home is {{.Env.HOME}}.",
+			error: `template: comment:1:14: environment variable "HOME" is not allowed by -env-allow`,
+		},
+		{
+			title: "disallowed variable with index",
+			body:  `Home is {{index .Env "HOME"}}.`,
+			error: `template: comment:1:21: environment variable "HOME" is not allowed by -env-allow`,
+		},
+		{
+			title: "disallowed variable with index of root",
+			body:  `{{with .Pull}}Home is {{index $.Env "HOME"}}.{{end}}`,
+			error: `template: comment:1:36: environment variable "HOME" is not allowed by -env-allow`,
+		},
+		{
+			title:    "missing variable",
+			body:     "Missing is {{.Env.MISSING}}.",
+			expected: "Missing is <no value>.",
+		},
+		{
+			title:    "unknown key in another map",
+			body:     "Build is {{.Build.Nmuber}}.",
+			expected: "Build is <no value>.",
+		},
+		{
+			title:    "allowed variable with function",
+			body:     `Status is {{env "STATUS"}}.`,
+			expected: "Status is passed.",
+		},
+		{
+			title: "disallowed variable with function",
+			body:  `Home is {{env "HOME"}}.`,
+			error: `template: comment:1:10: executing "comment" at <env "HOME">: error calling env: environment variable "HOME" is not allowed by -env-allow`,
+		},
+		{
+			title: "missing variable with function",
+			body:  `Missing is {{env "MISSING"}}.`,
+			error: `template: comment:1:13: executing "comment" at <env "MISSING">: error calling env: environment variable "MISSING" is not set`,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			tpl, cf, err := NewTemplate([]TemplateFile{{Name: "comment", Body: []byte(test.body)}}, "")
+			assert.Nil(t, err)
+			cf.Allowlist = allowlist
+
+			var actual string
+			if err = ValidateEnv(tpl, allowlist); err == nil {
+				ctx := NewContext(filtered, filtered, circleCI{}, nil, &github.Issue{}, "default")
+				actual, err = Execute(tpl, ctx, cf)
+			}
+
+			if test.error != "" {
+				assert.EqualError(t, err, test.error)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, withoutMetadata(t, "default", actual))
+		})
+	}
+}
+
+func TestAllowlistContext(t *testing.T) {
+	env := map[string]string{
+		"CIRCLE_BUILD_NUM": "42",
+		"CIRCLE_SHA1":      "c0ffee",
+		"FOO":              "bar",
+	}
+
+	allowlist, err := NewAllowlist([]string{"FOO"})
+	assert.Nil(t, err)
+
+	// Build information is read from the complete environment, even when the
+	// variables behind it are not allowed.
+	ctx := NewContext(env, allowlist.Filter(env), circleCI{}, nil, &github.Issue{}, "default")
+	assert.Equal(t, map[string]string{"FOO": "bar"}, ctx.Env)
+	assert.Equal(t, "42", ctx.Build["Number"])
+	assert.Equal(t, "c0ffee", ctx.Git["SHA"])
+
+	meta := NewMetadata(ctx, "")
+	assert.Equal(t, "42", meta.Build)
+	assert.Equal(t, "c0ffee", meta.SHA)
+}
+
+func TestReadAllowlistFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hub-comment")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "allow.txt")
+	body := "# Build information\nCIRCLE_*\n\n  STATUS  \n"
+	if err := ioutil.WriteFile(filename, []byte(body), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	patterns, err := ReadAllowlistFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"CIRCLE_*", "STATUS"}, patterns)
+}
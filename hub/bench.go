@@ -0,0 +1,469 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// benchAlpha is the p-value below which a change in a benchmark is considered
+// to be statistically significant, and not just noise.
+const benchAlpha = 0.05
+
+// reBenchProcs matches the GOMAXPROCS suffix that "go test -bench" appends to
+// the name of every benchmark, like the "-8" in "BenchmarkParse-8".
+var reBenchProcs = regexp.MustCompile(`-[0-9]+$`)
+
+// BenchmarkResult represents every sample of a single benchmark, as read from
+// the output of "go test -bench".
+type BenchmarkResult struct {
+	// Package is the import path of the package containing the benchmark.
+	Package string
+
+	// Name is the name of the benchmark, without the "Benchmark" prefix or
+	// GOMAXPROCS suffix.
+	Name string
+
+	// Samples is every measured value, keyed by unit, like "ns/op", "B/op",
+	// or "allocs/op". Running benchmarks with -count gives many samples.
+	Samples map[string][]float64
+}
+
+// Benchmarks represents the comparison of a base and a head set of benchmark
+// results, like those from the target branch and from a pull request.
+type Benchmarks struct {
+	// Threshold is the percentage that a significant change must exceed to
+	// count as a regression or an improvement.
+	Threshold float64
+
+	// Comparisons is the list of every benchmark, in order of appearance.
+	Comparisons []BenchmarkComparison
+}
+
+// BenchmarkComparison represents the comparison of a single benchmark.
+type BenchmarkComparison struct {
+	// Package is the import path of the package containing the benchmark.
+	Package string
+
+	// Name is the name of the benchmark.
+	Name string
+
+	// Metrics is the comparison of every unit measured by the benchmark, with
+	// ns/op, B/op, and allocs/op first.
+	Metrics []BenchmarkMetric
+}
+
+// BenchmarkMetric represents the comparison of a single unit measured by a
+// single benchmark.
+type BenchmarkMetric struct {
+	// Unit is the unit of measurement, like "ns/op".
+	Unit string
+
+	// Base is the median of the base samples.
+	Base float64
+
+	// Head is the median of the head samples.
+	Head float64
+
+	// BaseSamples is the number of base samples. Zero if the benchmark is
+	// new.
+	BaseSamples int
+
+	// HeadSamples is the number of head samples. Zero if the benchmark was
+	// removed.
+	HeadSamples int
+
+	// Delta is the percentage change from Base to Head, rounded to two
+	// decimal places.
+	Delta float64
+
+	// P is the p-value of the change, as given by a Mann-Whitney U-test.
+	P float64
+
+	// Significant is true if the change is unlikely to be noise.
+	Significant bool
+
+	// Regression is true if the change is significant, and is worse than
+	// the threshold.
+	Regression bool
+
+	// Improvement is true if the change is significant, and is better than
+	// the threshold.
+	Improvement bool
+}
+
+// ReadBench reads the output of "go test -bench", and returns every benchmark
+// it contains. Benchmarks that were run many times are combined into a single
+// result. Lines that are not benchmark results are ignored.
+func ReadBench(r io.Reader) ([]BenchmarkResult, error) {
+	var (
+		results []BenchmarkResult
+		index   = map[string]int{}
+		pkg     string
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "pkg: ") {
+			pkg = strings.TrimPrefix(line, "pkg: ")
+			continue
+		}
+
+		// Lines look like "BenchmarkParse-8  1000  1052 ns/op  128 B/op".
+		fields := strings.Fields(line)
+		if len(fields) < 4 || len(fields)%2 != 0 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+
+		name := reBenchProcs.ReplaceAllString(strings.TrimPrefix(fields[0], "Benchmark"), "")
+		key := pkg + "\x00" + name
+		if _, found := index[key]; !found {
+			index[key] = len(results)
+			results = append(results, BenchmarkResult{
+				Package: pkg,
+				Name:    name,
+				Samples: map[string][]float64{},
+			})
+		}
+
+		result := &results[index[key]]
+		for i := 2; i < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed benchmark result %q", line)
+			}
+			result.Samples[fields[i+1]] = append(result.Samples[fields[i+1]], value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CompareBenchmarks compares the given base and head benchmark results. Any
+// significant change worse than the threshold percentage is a regression.
+func CompareBenchmarks(base []BenchmarkResult, head []BenchmarkResult, threshold float64) *Benchmarks {
+	var (
+		bench = &Benchmarks{Threshold: threshold}
+		bases = benchIndex(base)
+		heads = benchIndex(head)
+		seen  = map[string]bool{}
+	)
+
+	// Benchmarks are listed in the order they were run, followed by any that
+	// were removed.
+	for _, result := range append(append([]BenchmarkResult{}, head...), base...) {
+		key := result.Package + "\x00" + result.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		var (
+			before = bases[key].Samples
+			after  = heads[key].Samples
+		)
+
+		comparison := BenchmarkComparison{Package: result.Package, Name: result.Name}
+		for _, unit := range benchUnits(before, after) {
+			comparison.Metrics = append(comparison.Metrics, compareBenchmark(unit, before[unit], after[unit], threshold))
+		}
+		bench.Comparisons = append(bench.Comparisons, comparison)
+	}
+	return bench
+}
+
+// benchIndex returns a map of the given results, keyed by package and name.
+func benchIndex(results []BenchmarkResult) map[string]BenchmarkResult {
+	index := make(map[string]BenchmarkResult, len(results))
+	for _, result := range results {
+		index[result.Package+"\x00"+result.Name] = result
+	}
+	return index
+}
+
+// benchUnits returns the union of every unit measured in the given samples,
+// with ns/op, B/op, and allocs/op first, and any others sorted after.
+func benchUnits(samples ...map[string][]float64) []string {
+	var (
+		rank  = map[string]int{"ns/op": 1, "B/op": 2, "allocs/op": 3}
+		units []string
+		seen  = map[string]bool{}
+	)
+	for _, sample := range samples {
+		for unit := range sample {
+			if !seen[unit] {
+				seen[unit] = true
+				units = append(units, unit)
+			}
+		}
+	}
+
+	sort.Slice(units, func(i, j int) bool {
+		a, b := rank[units[i]], rank[units[j]]
+		switch {
+		case a != 0 && b != 0:
+			return a < b
+		case a != 0 || b != 0:
+			return a != 0
+		default:
+			return units[i] < units[j]
+		}
+	})
+	return units
+}
+
+// compareBenchmark compares the given base and head samples of a single unit.
+func compareBenchmark(unit string, base []float64, head []float64, threshold float64) BenchmarkMetric {
+	metric := BenchmarkMetric{
+		Unit:        unit,
+		Base:        median(base),
+		Head:        median(head),
+		BaseSamples: len(base),
+		HeadSamples: len(head),
+		P:           mannWhitneyU(base, head),
+	}
+	if len(base) == 0 || len(head) == 0 {
+		return metric
+	}
+
+	switch {
+	case metric.Base == metric.Head:
+	case metric.Base == 0:
+		metric.Delta = math.Inf(1)
+	default:
+		metric.Delta, _ = strconv.ParseFloat(strconv.FormatFloat(100*(metric.Head-metric.Base)/metric.Base, 'f', 2, 64), 64)
+	}
+
+	// Lower is better, except for rates, like "MB/s".
+	worse := metric.Delta
+	if strings.HasSuffix(unit, "/s") {
+		worse = -worse
+	}
+
+	metric.Significant = metric.P < benchAlpha
+	metric.Regression = metric.Significant && worse > threshold
+	metric.Improvement = metric.Significant && -worse > threshold
+	return metric
+}
+
+// Regressions returns every benchmark with at least one regression.
+func (b *Benchmarks) Regressions() []BenchmarkComparison {
+	var regressions []BenchmarkComparison
+	for _, comparison := range b.Comparisons {
+		if comparison.Regressed() {
+			regressions = append(regressions, comparison)
+		}
+	}
+	return regressions
+}
+
+// Improvements returns every benchmark with at least one improvement, and no
+// regressions.
+func (b *Benchmarks) Improvements() []BenchmarkComparison {
+	var improvements []BenchmarkComparison
+	for _, comparison := range b.Comparisons {
+		if comparison.Improved() && !comparison.Regressed() {
+			improvements = append(improvements, comparison)
+		}
+	}
+	return improvements
+}
+
+// Regressed returns true if any unit measured by the benchmark regressed.
+func (c BenchmarkComparison) Regressed() bool {
+	for _, metric := range c.Metrics {
+		if metric.Regression {
+			return true
+		}
+	}
+	return false
+}
+
+// Improved returns true if any unit measured by the benchmark improved.
+func (c BenchmarkComparison) Improved() bool {
+	for _, metric := range c.Metrics {
+		if metric.Improvement {
+			return true
+		}
+	}
+	return false
+}
+
+// Metric returns the comparison of the given unit, or nil if the benchmark did
+// not measure that unit.
+func (c BenchmarkComparison) Metric(unit string) *BenchmarkMetric {
+	for index := range c.Metrics {
+		if c.Metrics[index].Unit == unit {
+			return &c.Metrics[index]
+		}
+	}
+	return nil
+}
+
+// Change returns a short description of the change, like "+12.5% (p=0.008)",
+// or "~ (p=0.310)" if the change was not significant. An empty string is
+// returned if the benchmark is new or was removed.
+func (m BenchmarkMetric) Change() string {
+	switch {
+	case m.BaseSamples == 0 || m.HeadSamples == 0:
+		return ""
+	case !m.Significant:
+		return fmt.Sprintf("~ (p=%.3f)", m.P)
+	default:
+		return fmt.Sprintf("%+.2f%% (p=%.3f)", m.Delta, m.P)
+	}
+}
+
+// Format returns the given value in the unit of the metric, scaled to a human
+// readable size, like "1.052µs" for 1052 ns/op, or "1.5KiB" for 1536 B/op.
+func (m BenchmarkMetric) Format(value float64) string {
+	var (
+		scales []float64
+		names  []string
+	)
+	switch m.Unit {
+	case "ns/op":
+		scales, names = []float64{1, 1e3, 1e6, 1e9}, []string{"ns", "µs", "ms", "s"}
+	case "B/op":
+		scales, names = []float64{1, 1 << 10, 1 << 20, 1 << 30}, []string{"B", "KiB", "MiB", "GiB"}
+	default:
+		return strconv.FormatFloat(value, 'g', 4, 64)
+	}
+
+	index := 0
+	for index < len(scales)-1 && math.Abs(value) >= scales[index+1] {
+		index++
+	}
+	return strconv.FormatFloat(value/scales[index], 'g', 4, 64) + names[index]
+}
+
+// median returns the median of the given values, or zero if there are none.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// mannWhitneyU returns the two-sided p-value of a Mann-Whitney U-test of the
+// given samples, which is the probability that the difference between them is
+// due to chance alone. The exact distribution is used for small samples
+// without ties, and a normal approximation otherwise.
+func mannWhitneyU(x []float64, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type sample struct {
+		value float64
+		first bool
+	}
+	all := make([]sample, 0, n1+n2)
+	for _, value := range x {
+		all = append(all, sample{value, true})
+	}
+	for _, value := range y {
+		all = append(all, sample{value, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	// Rank every sample, giving tied samples the average of their ranks.
+	var (
+		rankSum float64
+		ties    float64
+	)
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				rankSum += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties += t*t*t - t
+		}
+		i = j
+	}
+
+	u1 := rankSum - float64(n1*(n1+1))/2
+	u := math.Min(u1, float64(n1*n2)-u1)
+
+	if ties == 0 && n1 <= 20 && n2 <= 20 {
+		return math.Min(1, 2*mannWhitneyExact(n1, n2, int(u)))
+	}
+
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - ties/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		return 1
+	}
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// mannWhitneyExact returns the probability of a U statistic less than or equal
+// to u, for samples of size n1 and n2 without ties.
+func mannWhitneyExact(n1 int, n2 int, u int) float64 {
+	// counts[i][j][k] is the number of orderings of i and j samples with a U
+	// statistic of k, built with the recurrence:
+	//   f(i, j, k) = f(i-1, j, k-j) + f(i, j-1, k)
+	counts := make([][][]float64, n1+1)
+	for i := range counts {
+		counts[i] = make([][]float64, n2+1)
+		for j := range counts[i] {
+			counts[i][j] = make([]float64, i*j+1)
+			if i == 0 || j == 0 {
+				counts[i][j][0] = 1
+				continue
+			}
+			for k := range counts[i][j] {
+				if k-j >= 0 && k-j < len(counts[i-1][j]) {
+					counts[i][j][k] += counts[i-1][j][k-j]
+				}
+				if k < len(counts[i][j-1]) {
+					counts[i][j][k] += counts[i][j-1][k]
+				}
+			}
+		}
+	}
+
+	var below, total float64
+	for k, count := range counts[n1][n2] {
+		if k <= u {
+			below += count
+		}
+		total += count
+	}
+	return below / total
+}
@@ -0,0 +1,306 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBench(t *testing.T) {
+	tests := []struct {
+		title    string
+		body     string
+		expected []BenchmarkResult
+		error    string
+	}{
+		{
+			title: "no benchmarks",
+			body:  "PASS\nok  \texample.com/app\t0.010s\n",
+		},
+		{
+			title: "many packages and samples",
+			body: `goos: linux
+goarch: amd64
+pkg: example.com/app
+cpu: Intel(R) Xeon(R) CPU @ 2.20GHz
+BenchmarkParse-8    	 1000000	      1052 ns/op	     128 B/op	       2 allocs/op
+BenchmarkParse-8    	 1000000	      1048 ns/op	     128 B/op	       2 allocs/op
+BenchmarkParse/large-8         	    5000	    250000 ns/op	  48.50 MB/s
+PASS
+ok  	example.com/app	3.012s
+pkg: example.com/app/hub
+BenchmarkRender 	   20000	     61234 ns/op
+PASS
+ok  	example.com/app/hub	1.503s
+`,
+			expected: []BenchmarkResult{
+				{
+					Package: "example.com/app",
+					Name:    "Parse",
+					Samples: map[string][]float64{
+						"ns/op":     {1052, 1048},
+						"B/op":      {128, 128},
+						"allocs/op": {2, 2},
+					},
+				},
+				{
+					Package: "example.com/app",
+					Name:    "Parse/large",
+					Samples: map[string][]float64{
+						"ns/op": {250000},
+						"MB/s":  {48.5},
+					},
+				},
+				{
+					Package: "example.com/app/hub",
+					Name:    "Render",
+					Samples: map[string][]float64{
+						"ns/op": {61234},
+					},
+				},
+			},
+		},
+		{
+			title: "malformed value",
+			body:  "BenchmarkParse-8 1000 fast ns/op\n",
+			error: `malformed benchmark result "BenchmarkParse-8 1000 fast ns/op"`,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			actual, err := ReadBench(strings.NewReader(test.body))
+			if test.error != "" {
+				assert.EqualError(t, err, test.error)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	tests := []struct {
+		title    string
+		x        []float64
+		y        []float64
+		expected string
+	}{
+		{
+			title:    "no samples",
+			y:        []float64{1, 2, 3},
+			expected: "1.0000",
+		},
+		{
+			title:    "single samples",
+			x:        []float64{1},
+			y:        []float64{2},
+			expected: "1.0000",
+		},
+		{
+			title:    "separated samples",
+			x:        []float64{1, 2, 3, 4, 5},
+			y:        []float64{6, 7, 8, 9, 10},
+			expected: "0.0079",
+		},
+		{
+			title:    "interleaved samples",
+			x:        []float64{1, 3, 5, 7, 9},
+			y:        []float64{2, 4, 6, 8, 10},
+			expected: "0.6905",
+		},
+		{
+			title:    "identical samples",
+			x:        []float64{2, 2, 2, 2, 2},
+			y:        []float64{2, 2, 2, 2, 2},
+			expected: "1.0000",
+		},
+		{
+			title:    "tied samples",
+			x:        []float64{2, 2, 2, 2, 2},
+			y:        []float64{3, 3, 3, 3, 3},
+			expected: "0.0040",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, fmt.Sprintf("%.4f", mannWhitneyU(test.x, test.y)))
+		})
+	}
+}
+
+func TestCompareBenchmarks(t *testing.T) {
+	var (
+		base = []BenchmarkResult{
+			{Name: "Parse", Samples: map[string][]float64{"ns/op": {100, 101, 102, 103, 104}, "B/op": {64, 64, 64, 64, 64}}},
+			{Name: "Render", Samples: map[string][]float64{"ns/op": {200, 201, 202, 203, 204}}},
+			{Name: "Removed", Samples: map[string][]float64{"ns/op": {50}}},
+		}
+		head = []BenchmarkResult{
+			{Name: "Parse", Samples: map[string][]float64{"ns/op": {120, 121, 122, 123, 124}, "B/op": {64, 64, 64, 64, 64}}},
+			{Name: "Render", Samples: map[string][]float64{"ns/op": {150, 151, 152, 153, 154}}},
+			{Name: "Added", Samples: map[string][]float64{"ns/op": {75}}},
+		}
+	)
+
+	bench := CompareBenchmarks(base, head, 5)
+
+	var names []string
+	for _, comparison := range bench.Comparisons {
+		names = append(names, comparison.Name)
+	}
+	assert.Equal(t, []string{"Parse", "Render", "Added", "Removed"}, names)
+
+	parse := bench.Comparisons[0]
+	assert.Equal(t, "ns/op", parse.Metrics[0].Unit)
+	assert.Equal(t, "B/op", parse.Metrics[1].Unit)
+	assert.Equal(t, 19.61, parse.Metric("ns/op").Delta)
+	assert.True(t, parse.Metric("ns/op").Regression)
+	assert.False(t, parse.Metric("B/op").Significant)
+	assert.Nil(t, parse.Metric("allocs/op"))
+	assert.Equal(t, "+19.61% (p=0.008)", parse.Metric("ns/op").Change())
+	assert.Equal(t, "~ (p=1.000)", parse.Metric("B/op").Change())
+
+	render := bench.Comparisons[1]
+	assert.True(t, render.Improved())
+	assert.False(t, render.Regressed())
+
+	assert.Equal(t, "", bench.Comparisons[2].Metric("ns/op").Change())
+	assert.Equal(t, 0, bench.Comparisons[3].Metric("ns/op").HeadSamples)
+
+	assert.Len(t, bench.Regressions(), 1)
+	assert.Len(t, bench.Improvements(), 1)
+	assert.Len(t, CompareBenchmarks(base, head, 25).Regressions(), 0)
+}
+
+func TestBenchmarkMetricFormat(t *testing.T) {
+	tests := []struct {
+		title    string
+		unit     string
+		value    float64
+		expected string
+	}{
+		{
+			title:    "nanoseconds",
+			unit:     "ns/op",
+			value:    0.25,
+			expected: "0.25ns",
+		},
+		{
+			title:    "microseconds",
+			unit:     "ns/op",
+			value:    1052,
+			expected: "1.052µs",
+		},
+		{
+			title:    "seconds",
+			unit:     "ns/op",
+			value:    2.5e9,
+			expected: "2.5s",
+		},
+		{
+			title:    "kibibytes",
+			unit:     "B/op",
+			value:    1536,
+			expected: "1.5KiB",
+		},
+		{
+			title:    "other units",
+			unit:     "allocs/op",
+			value:    12,
+			expected: "12",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, BenchmarkMetric{Unit: test.unit}.Format(test.value))
+		})
+	}
+}
+
+func TestBenchTemplate(t *testing.T) {
+	var (
+		base = []BenchmarkResult{
+			{Name: "Parse", Samples: map[string][]float64{"ns/op": {100, 101, 102, 103, 104}, "B/op": {64, 64, 64, 64, 64}}},
+		}
+		head = []BenchmarkResult{
+			{Name: "Parse", Samples: map[string][]float64{"ns/op": {120, 121, 122, 123, 124}, "B/op": {64, 64, 64, 64, 64}}},
+			{Name: "Added", Samples: map[string][]float64{"ns/op": {2000}}},
+		}
+	)
+
+	expected := "### ❌ 1 benchmark regressed\n\n" +
+		"| Benchmark | Unit | Base | Head | Change |\n" +
+		"|-----------|------|-----:|-----:|-------:|\n" +
+		"| ❌ `Parse` | ns/op | 102ns | 122ns | +19.61% (p=0.008) |\n" +
+		"| `Parse` | B/op | 64B | 64B | ~ (p=1.000) |\n" +
+		"| `Added` | ns/op |  | 2µs |  |\n\n" +
+		"Changes with p < 0.05 are significant. Significant changes over 5% are highlighted."
+
+	actual := renderBuiltin(t, "bench", func(ctx *Context) {
+		ctx.Bench = CompareBenchmarks(base, head, 5)
+	})
+	assert.Equal(t, expected, actual)
+}
+
+func TestBenchTemplateHeading(t *testing.T) {
+	var (
+		slow = map[string][]float64{"ns/op": {120, 121, 122, 123, 124}}
+		base = map[string][]float64{"ns/op": {100, 101, 102, 103, 104}}
+		fast = map[string][]float64{"ns/op": {80, 81, 82, 83, 84}}
+	)
+
+	tests := []struct {
+		title    string
+		base     []BenchmarkResult
+		head     []BenchmarkResult
+		expected string
+	}{
+		{
+			title:    "one regression",
+			base:     []BenchmarkResult{{Name: "Parse", Samples: base}},
+			head:     []BenchmarkResult{{Name: "Parse", Samples: slow}},
+			expected: "### ❌ 1 benchmark regressed",
+		},
+		{
+			title:    "two regressions",
+			base:     []BenchmarkResult{{Name: "Parse", Samples: base}, {Name: "Render", Samples: base}},
+			head:     []BenchmarkResult{{Name: "Parse", Samples: slow}, {Name: "Render", Samples: slow}},
+			expected: "### ❌ 2 benchmarks regressed",
+		},
+		{
+			title:    "one improvement",
+			base:     []BenchmarkResult{{Name: "Parse", Samples: base}},
+			head:     []BenchmarkResult{{Name: "Parse", Samples: fast}},
+			expected: "### 🚀 1 benchmark improved",
+		},
+		{
+			title:    "no changes",
+			base:     []BenchmarkResult{{Name: "Parse", Samples: base}},
+			head:     []BenchmarkResult{{Name: "Parse", Samples: base}},
+			expected: "### ✅ No benchmarks regressed",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			actual := renderBuiltin(t, "bench", func(ctx *Context) {
+				ctx.Bench = CompareBenchmarks(test.base, test.head, 5)
+			})
+			assert.Equal(t, test.expected, strings.SplitN(actual, "\n", 2)[0])
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"sort"
+)
+
+// testsTemplate is a built-in template summarizing the test results in
+// Context.Tests, along with a table of failures and their stack traces.
+const testsTemplate = `
+{{- with .Tests -}}
+{{- if .OK -}}
+### ✅ {{if eq .Total 1}}1 test passed{{else}}All {{.Total}} tests passed{{end}}
+{{- else -}}
+### ❌ {{.Failed}} of {{plural "test" "tests" .Total}} failed
+{{- end}}
+
+{{.Passed}} passed, {{.Failed}} failed, and {{.Skipped}} skipped in {{.Duration}}.
+{{- if .Failures}}
+
+| Suite | Test | Message |
+|-------|------|---------|
+{{- range .Failures}}
+| {{tableCell .Suite}} | {{tableCell .Name}} | {{tableCell .Message}} |
+{{- end}}
+{{- range .Failures}}
+{{- if .Output}}
+
+{{details (printf "%s %s" .Suite .Name) .Output}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+`
+
+// goTestTemplate is a built-in template summarizing the results of "go test
+// -json" in Context.Tests, with a row for every package, along with the output
+// of every failed test.
+const goTestTemplate = `
+{{- with .Tests -}}
+{{- if .OK -}}
+### ✅ {{if eq .Total 1}}1 Go test passed{{else}}All {{.Total}} Go tests passed{{end}}
+{{- else -}}
+### ❌ {{.Failed}} of {{plural "Go test" "Go tests" .Total}} failed
+{{- end}}
+
+| Package | Passed | Failed | Skipped | Time |
+|---------|-------:|-------:|--------:|-----:|
+{{- range .Suites}}
+| {{if .Failed}}❌{{else}}✅{{end}} ` + "`{{.Name}}`" + ` | {{.Passed}} | {{.Failed}} | {{.Skipped}} | {{.Duration}} |
+{{- end}}
+{{- range .Failures}}
+
+{{details (printf "%s %s" .Suite .Name) (.Output | default .Message)}}
+{{- end}}
+{{- end}}
+`
+
+// coverageTemplate is a built-in template summarizing the Go coverage profile
+// in Context.Coverage, with a row for every package, along with the change in
+// total coverage since the previous comment.
+const coverageTemplate = `
+{{- with .Coverage -}}
+### 📊 Coverage is {{printf "%.1f%%" .Total}}
+{{- if .HasPrevious}} ({{printf "%+.1f%%" .Delta}} vs last build){{end}}
+
+{{.Covered}} of {{.Statements}} statements covered.
+
+| Package | Statements | Coverage |
+|---------|-----------:|---------:|
+{{- range .Packages}}
+| ` + "`{{.Name}}`" + ` | {{.Covered}}/{{.Statements}} | {{printf "%.1f%%" .Percent}} |
+{{- end}}
+{{- end}}
+`
+
+// lintTemplate is a built-in template summarizing the lint findings in
+// Context.Lint, along with a table of every finding.
+const lintTemplate = `
+{{- with .Lint -}}
+{{- if .Total -}}
+### {{if .Errors}}❌{{else}}⚠️{{end}} {{plural "lint finding" "lint findings" .Total}}
+{{- else -}}
+### ✅ No lint findings
+{{- end}}
+
+{{plural "error" "errors" .Errors}}, {{plural "warning" "warnings" .Warnings}}, and {{plural "note" "notes" .Notes}}.
+{{- if .Findings}}
+
+{{findings .Findings}}
+{{- end}}
+{{- end}}
+`
+
+// benchTemplate is a built-in template comparing the base and head benchmark
+// results in Context.Bench, with a row for every unit of every benchmark.
+// Significant regressions and improvements are highlighted.
+const benchTemplate = `
+{{- with .Bench -}}
+{{- if .Regressions -}}
+### ❌ {{plural "benchmark" "benchmarks" (len .Regressions)}} regressed
+{{- else if .Improvements -}}
+### 🚀 {{plural "benchmark" "benchmarks" (len .Improvements)}} improved
+{{- else -}}
+### ✅ No benchmarks regressed
+{{- end}}
+
+| Benchmark | Unit | Base | Head | Change |
+|-----------|------|-----:|-----:|-------:|
+{{- range .Comparisons}}
+{{- $name := .Name}}
+{{- range .Metrics}}
+| {{if .Regression}}❌ {{else if .Improvement}}🚀 {{end}}` + "`{{$name}}`" + ` | {{.Unit}} | {{if .BaseSamples}}{{.Format .Base}}{{end}} | {{if .HeadSamples}}{{.Format .Head}}{{end}} | {{.Change}} |
+{{- end}}
+{{- end}}
+
+Changes with p < 0.05 are significant. Significant changes over {{.Threshold}}% are highlighted.
+{{- end}}
+`
+
+// builtinTemplates maps the name of every built-in template to its body.
+var builtinTemplates = map[string]string{
+	"bench":    benchTemplate,
+	"coverage": coverageTemplate,
+	"gotest":   goTestTemplate,
+	"lint":     lintTemplate,
+	"tests":    testsTemplate,
+}
+
+// BuiltinTemplate returns the built-in template with the given name.
+func BuiltinTemplate(name string) (TemplateFile, bool) {
+	body, found := builtinTemplates[name]
+	return TemplateFile{Name: name, Body: []byte(body)}, found
+}
+
+// BuiltinTemplateNames returns the names of every built-in template, sorted.
+func BuiltinTemplateNames() []string {
+	names := make([]string, 0, len(builtinTemplates))
+	for name := range builtinTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
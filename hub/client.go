@@ -6,20 +6,107 @@ package hub
 
 import (
 	"context"
+	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/google/go-github/github"
 	"golang.org/x/oauth2"
 )
 
-// NewClient builds a GitHub client that is authenticated with the given token.
-func NewClient(ctx context.Context, token string) *github.Client {
+const (
+	// defaultHost is the host of the public GitHub server.
+	defaultHost = "github.com"
+
+	// defaultAPIURL is the API endpoint of the public GitHub server.
+	defaultAPIURL = "https://api.github.com/"
+)
+
+// APIURL returns the API endpoint for the GitHub server with the given host.
+// Any host other than github.com is assumed to be a GitHub Enterprise server.
+func APIURL(host string) string {
+	if host == "" || host == defaultHost {
+		return defaultAPIURL
+	}
+	return fmt.Sprintf("https://%s/api/v3/", host)
+}
+
+// uploadURL returns the upload endpoint that corresponds with the given API
+// endpoint.
+func uploadURL(apiURL string) string {
+	if strings.HasSuffix(apiURL, "/api/v3/") {
+		return strings.TrimSuffix(apiURL, "/api/v3/") + "/api/uploads/"
+	}
+	return apiURL
+}
+
+// CheckAPIURL verifies that the given API endpoint belongs to the GitHub server
+// with the given host, so that API requests are not sent to a different server
+// than the one hosting the pull request.
+func CheckAPIURL(apiURL string, host string) error {
+	endpoint, err := url.Parse(apiURL)
+	if err != nil {
+		return err
+	}
+
+	if host == "" {
+		host = defaultHost
+	}
+
+	switch endpoint.Host {
+	case host, "api." + host:
+		return nil
+	default:
+		return fmt.Errorf("api url %s does not belong to %s", apiURL, host)
+	}
+}
+
+// ResolveAPIURL chooses the API endpoint to use for the GitHub server with the
+// given host. An explicit endpoint is always used, after checking that it
+// belongs to the host. Otherwise, the endpoint from the environment is used if
+// it belongs to the host, and if not, the endpoint is inferred from the host.
+// Hosts are only checked when known, since short references do not name one.
+func ResolveAPIURL(explicit string, environment string, host string) (string, error) {
+	switch {
+	case explicit != "" && host != "":
+		if err := CheckAPIURL(explicit, host); err != nil {
+			return "", err
+		}
+		return explicit, nil
+	case explicit != "":
+		return explicit, nil
+	case environment != "" && host == "":
+		return environment, nil
+	case environment != "" && CheckAPIURL(environment, host) == nil:
+		return environment, nil
+	default:
+		return APIURL(host), nil
+	}
+}
+
+// NewClient builds a pair of GitHub clients, for the REST and GraphQL APIs,
+// that are authenticated with the given token, and which send requests to the
+// given API endpoint.
+func NewClient(ctx context.Context, token string, apiURL string) (*github.Client, *GraphQLClient, error) {
 	var (
 		tokenSource = oauth2.StaticTokenSource(
 			&oauth2.Token{AccessToken: token},
 		)
 		httpClient = oauth2.NewClient(ctx, tokenSource)
 	)
-	return github.NewClient(httpClient)
+
+	if apiURL == "" || apiURL == defaultAPIURL {
+		return github.NewClient(httpClient), &GraphQLClient{URL: defaultGraphQLURL, client: httpClient}, nil
+	}
+
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+	client, err := github.NewEnterpriseClient(apiURL, uploadURL(apiURL), httpClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, &GraphQLClient{URL: graphQLURL(apiURL), client: httpClient}, nil
 }
 
 // getSelf retrieves information about the current authenticated user.
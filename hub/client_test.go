@@ -0,0 +1,166 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		title      string
+		host       string
+		apiURL     string
+		baseURL    string
+		uploadURL  string
+		graphQLURL string
+		invalid    bool
+	}{
+		{
+			title:      "default host",
+			baseURL:    "https://api.github.com/",
+			uploadURL:  "https://uploads.github.com/",
+			graphQLURL: "https://api.github.com/graphql",
+		},
+		{
+			title:      "github.com",
+			host:       "github.com",
+			baseURL:    "https://api.github.com/",
+			uploadURL:  "https://uploads.github.com/",
+			graphQLURL: "https://api.github.com/graphql",
+		},
+		{
+			title:      "enterprise host",
+			host:       "github.example.com",
+			baseURL:    "https://github.example.com/api/v3/",
+			uploadURL:  "https://github.example.com/api/uploads/",
+			graphQLURL: "https://github.example.com/api/graphql",
+		},
+		{
+			title:      "explicit api url",
+			host:       "github.example.com",
+			apiURL:     "https://github.example.com/api/v3",
+			baseURL:    "https://github.example.com/api/v3/",
+			uploadURL:  "https://github.example.com/api/uploads/",
+			graphQLURL: "https://github.example.com/api/graphql",
+		},
+		{
+			title:      "explicit api subdomain",
+			host:       "github.example.com",
+			apiURL:     "https://api.github.example.com/",
+			baseURL:    "https://api.github.example.com/",
+			uploadURL:  "https://api.github.example.com/",
+			graphQLURL: "https://api.github.example.com/graphql",
+		},
+		{
+			title:   "mismatched api url",
+			host:    "github.example.com",
+			apiURL:  "https://api.github.com/",
+			invalid: true,
+		},
+		{
+			title:   "mismatched enterprise api url",
+			host:    "github.com",
+			apiURL:  "https://github.example.com/api/v3/",
+			invalid: true,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			apiURL := test.apiURL
+			if apiURL == "" {
+				apiURL = APIURL(test.host)
+			}
+
+			err := CheckAPIURL(apiURL, test.host)
+			if test.invalid {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			client, gql, err := NewClient(context.Background(), "token", apiURL)
+			assert.Nil(t, err)
+			assert.Equal(t, test.baseURL, client.BaseURL.String())
+			assert.Equal(t, test.uploadURL, client.UploadURL.String())
+			assert.Equal(t, test.graphQLURL, gql.URL)
+		})
+	}
+}
+
+func TestResolveAPIURL(t *testing.T) {
+	tests := []struct {
+		title       string
+		explicit    string
+		environment string
+		host        string
+		expected    string
+		invalid     bool
+	}{
+		{
+			title:    "nothing given",
+			expected: "https://api.github.com/",
+		},
+		{
+			title:    "inferred from host",
+			host:     "github.example.com",
+			expected: "https://github.example.com/api/v3/",
+		},
+		{
+			title:       "environment without host",
+			environment: "https://github.example.com/api/v3",
+			expected:    "https://github.example.com/api/v3",
+		},
+		{
+			title:       "environment belonging to host",
+			environment: "https://github.example.com/api/v3",
+			host:        "github.example.com",
+			expected:    "https://github.example.com/api/v3",
+		},
+		{
+			title:       "environment for another host",
+			environment: "https://api.github.com",
+			host:        "github.example.com",
+			expected:    "https://github.example.com/api/v3/",
+		},
+		{
+			title:       "explicit overrides environment",
+			explicit:    "https://api.github.example.com/",
+			environment: "https://github.example.com/api/v3",
+			host:        "github.example.com",
+			expected:    "https://api.github.example.com/",
+		},
+		{
+			title:    "explicit without host",
+			explicit: "https://github.example.com/api/v3/",
+			expected: "https://github.example.com/api/v3/",
+		},
+		{
+			title:    "explicit for another host",
+			explicit: "https://api.github.com/",
+			host:     "github.example.com",
+			invalid:  true,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			actual, err := ResolveAPIURL(test.explicit, test.environment, test.host)
+			if test.invalid {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
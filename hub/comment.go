@@ -6,24 +6,138 @@ package hub
 
 import (
 	"context"
-	"strings"
 
 	"github.com/google/go-github/github"
 )
 
-// GetComments fetches all comments for the given pull request number.
+const (
+	// commentsPerPage is the number of comments requested in a single page.
+	// This is the largest page size that the GitHub API allows.
+	commentsPerPage = 100
+
+	// maxCommentPages is the maximum number of pages of comments that will be
+	// fetched for a single pull request. This caps the number of API requests
+	// made against exceptionally busy pull requests.
+	maxCommentPages = 50
+)
+
+// getCommentsPage fetches a single page of comments for the given pull request
+// number.
+func getCommentsPage(ctx context.Context, client *github.Client, owner string, repo string, number int, page int) ([]*github.IssueComment, *github.Response, error) {
+	opts := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{
+			Page:    page,
+			PerPage: commentsPerPage,
+		},
+	}
+	return client.Issues.ListComments(ctx, owner, repo, number, opts)
+}
+
+// GetComments fetches all comments for the given pull request number, following
+// pagination links until every page has been read.
 func GetComments(ctx context.Context, client *github.Client, owner string, repo string, number int) ([]*github.IssueComment, error) {
-	comments, _, err := client.Issues.ListComments(ctx, owner, repo, number, nil)
+	var comments []*github.IssueComment
+	for page, count := 1, 0; page != 0 && count < maxCommentPages; count++ {
+		batch, resp, err := getCommentsPage(ctx, client, owner, repo, number, page)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, batch...)
+		page = resp.NextPage
+	}
+	return comments, nil
+}
+
+// ScanComments fetches pages of comments for the given pull request number,
+// and passes each page to fn. Pages are visited newest-first, so that recent
+// comments are seen before older ones. Scanning stops early once fn returns
+// true.
+func ScanComments(ctx context.Context, client *github.Client, owner string, repo string, number int, fn func([]*github.IssueComment) bool) error {
+	// The first page must always be fetched in order to learn the total number
+	// of pages, even though it holds the oldest comments.
+	first, resp, err := getCommentsPage(ctx, client, owner, repo, number, 1)
+	if err != nil {
+		return err
+	}
+
+	// Walk backwards from the last page, stopping short of the first page
+	// which was already fetched.
+	for page, count := resp.LastPage, 1; page > 1 && count < maxCommentPages; page, count = page-1, count+1 {
+		batch, _, err := getCommentsPage(ctx, client, owner, repo, number, page)
+		if err != nil {
+			return err
+		}
+		if fn(batch) {
+			return nil
+		}
+	}
+
+	fn(first)
+	return nil
+}
+
+// FindComment searches through the comments for the given pull request number,
+// and returns the most recently updated comment that was authored by the
+// current user, if one exists. Every page is searched, up to the page limit,
+// as an older comment may have been updated more recently than a newer one.
+func FindComment(ctx context.Context, client *github.Client, owner string, repo string, number int, authorName string, typeName string) (*github.IssueComment, error) {
+	return findComment(ctx, client, owner, repo, number, authorName, typeName, false)
+}
+
+// FindTypedComment is like FindComment, but only considers comments that are
+// explicitly marked with the given type. Comments without any metadata, that
+// may have been posted by another tool sharing the same user, are never
+// returned. This should be used before any destructive action.
+func FindTypedComment(ctx context.Context, client *github.Client, owner string, repo string, number int, authorName string, typeName string) (*github.IssueComment, error) {
+	return findComment(ctx, client, owner, repo, number, authorName, typeName, true)
+}
+
+// findComment searches every page of comments for the most recently updated
+// comment that was authored by the current user.
+func findComment(ctx context.Context, client *github.Client, owner string, repo string, number int, authorName string, typeName string, strict bool) (*github.IssueComment, error) {
+	var best *github.IssueComment
+	err := ScanComments(ctx, client, owner, repo, number, func(comments []*github.IssueComment) bool {
+		if match := filterComments(comments, authorName, typeName, strict); match != nil {
+			if best == nil || match.GetUpdatedAt().After(best.GetUpdatedAt()) {
+				best = match
+			}
+		}
+		return false
+	})
 	if err != nil {
 		return nil, err
 	}
-	return comments, nil
+	return best, nil
 }
 
 // FilterComments selects the most recently updated comment, that was authored
 // by the current user, if any exist.
 func FilterComments(comments []*github.IssueComment, authorName string, typeName string) (int64, bool) {
+	if best := filterComments(comments, authorName, typeName, false); best != nil {
+		return best.GetID(), true
+	}
+	return 0, false
+}
+
+// filterComments selects the most recently updated comment, that was authored
+// by the current user, if any exist.
+func filterComments(comments []*github.IssueComment, authorName string, typeName string, strict bool) *github.IssueComment {
 	var best *github.IssueComment
+	for _, comment := range matchComments(comments, authorName, typeName, strict) {
+		// Keep any comment that has been more recently updated.
+		if best == nil || comment.GetUpdatedAt().After(best.GetUpdatedAt()) {
+			best = comment
+		}
+	}
+
+	return best
+}
+
+// matchComments selects every comment that was authored by the current user,
+// and that has a matching type, in order. Comments without any metadata match
+// every type, unless strict is true.
+func matchComments(comments []*github.IssueComment, authorName string, typeName string, strict bool) []*github.IssueComment {
+	var matches []*github.IssueComment
 	for _, comment := range comments {
 		user := comment.GetUser()
 		if user == nil {
@@ -36,24 +150,33 @@ func FilterComments(comments []*github.IssueComment, authorName string, typeName
 			continue
 		}
 
-		// Reject if the comment has a meta type, and that type doesn't match.
-		if strings.Contains(comment.GetBody(), metaTypePrefix) {
-			if !strings.Contains(comment.GetBody(), metaTypePrefix+typeName+")") {
-				continue
-			}
-
+		// Reject if the comment has metadata, and its type doesn't match.
+		meta, _, found := ParseComment(comment.GetBody())
+		if found && meta.Type != typeName {
+			continue
 		}
 
-		// Keep any comment that has been more recently updated.
-		if best == nil || comment.GetUpdatedAt().After(best.GetUpdatedAt()) {
-			best = comment
+		// Reject if the comment has no metadata, and a type is required.
+		if !found && strict {
+			continue
 		}
+
+		matches = append(matches, comment)
 	}
 
-	if best != nil {
-		return *best.ID, true
+	return matches
+}
+
+// FindComments fetches every comment for the given pull request number, and
+// returns those that were authored by the current user, and that are
+// explicitly marked with the given type, oldest first. Comments without any
+// metadata are never returned.
+func FindComments(ctx context.Context, client *github.Client, owner string, repo string, number int, authorName string, typeName string) ([]*github.IssueComment, error) {
+	comments, err := GetComments(ctx, client, owner, repo, number)
+	if err != nil {
+		return nil, err
 	}
-	return 0, false
+	return matchComments(comments, authorName, typeName, true), nil
 }
 
 // PostComment creates a new comment on the given PR.
@@ -79,3 +202,9 @@ func UpdateComment(ctx context.Context, client *github.Client, owner string, rep
 	}
 	return cmt.GetHTMLURL(), nil
 }
+
+// DeleteComment deletes an existing comment.
+func DeleteComment(ctx context.Context, client *github.Client, owner string, repo string, commentID int64) error {
+	_, err := client.Issues.DeleteComment(ctx, owner, repo, commentID)
+	return err
+}
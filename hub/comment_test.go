@@ -0,0 +1,345 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient builds a GitHub client that sends all API requests to the
+// given handler, instead of to api.github.com. The returned server must be
+// closed by the caller.
+func newTestClient(handler http.Handler) (*github.Client, *httptest.Server) {
+	server := httptest.NewServer(handler)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client, server
+}
+
+// fakeComments is a fake GitHub API that serves a paginated list of comments
+// for a single pull request, and records comment edits.
+type fakeComments struct {
+	// comments is the complete list of comments, oldest first.
+	comments []*github.IssueComment
+
+	// fetched is the list of page numbers requested, in order.
+	fetched []int
+
+	// edited is a map of comment IDs to their updated bodies.
+	edited map[int64]string
+
+	// deleted is the list of deleted comment IDs, in order.
+	deleted []int64
+}
+
+func (f *fakeComments) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		if page < 1 {
+			page = 1
+		}
+		if perPage < 1 {
+			perPage = 30
+		}
+		f.fetched = append(f.fetched, page)
+
+		last := (len(f.comments) + perPage - 1) / perPage
+		link := func(page int, rel string) string {
+			return fmt.Sprintf(`<%s?page=%d&per_page=%d>; rel="%s"`, r.URL.Path, page, perPage, rel)
+		}
+		links := link(last, "last")
+		if page < last {
+			links = link(page+1, "next") + ", " + links
+		}
+		w.Header().Set("Link", links)
+
+		start, end := (page-1)*perPage, page*perPage
+		if end > len(f.comments) {
+			end = len(f.comments)
+		}
+		json.NewEncoder(w).Encode(f.comments[start:end])
+
+	case http.MethodPatch:
+		var body github.IssueComment
+		json.NewDecoder(r.Body).Decode(&body)
+
+		var id int64
+		fmt.Sscanf(r.URL.Path, "/repos/joshdk/hub-comment/issues/comments/%d", &id)
+		f.edited[id] = body.GetBody()
+
+		json.NewEncoder(w).Encode(&github.IssueComment{
+			ID:      github.Int64(id),
+			Body:    body.Body,
+			HTMLURL: github.String(fmt.Sprintf("https://github.com/joshdk/hub-comment/pull/123#issuecomment-%d", id)),
+		})
+
+	case http.MethodDelete:
+		var id int64
+		fmt.Sscanf(r.URL.Path, "/repos/joshdk/hub-comment/issues/comments/%d", &id)
+		f.deleted = append(f.deleted, id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// newFakeComments generates a fake comment API holding the given number of
+// comments, all authored by an uninteresting user.
+func newFakeComments(count int) *fakeComments {
+	var (
+		comments = make([]*github.IssueComment, count)
+		epoch    = time.Date(2018, 9, 14, 0, 0, 0, 0, time.UTC)
+	)
+
+	for index := range comments {
+		updated := epoch.Add(time.Duration(index) * time.Minute)
+		comments[index] = &github.IssueComment{
+			ID:        github.Int64(int64(index + 1)),
+			Body:      github.String(fmt.Sprintf("comment #%d", index+1)),
+			User:      &github.User{Login: github.String("someone")},
+			UpdatedAt: &updated,
+		}
+	}
+
+	return &fakeComments{
+		comments: comments,
+		edited:   map[int64]string{},
+	}
+}
+
+func TestGetComments(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		fake = newFakeComments(commentsPerPage*3 + 7)
+	)
+
+	client, server := newTestClient(fake)
+	defer server.Close()
+
+	comments, err := GetComments(ctx, client, "joshdk", "hub-comment", 123)
+
+	assert.Nil(t, err)
+	assert.Len(t, comments, commentsPerPage*3+7)
+	assert.Equal(t, []int{1, 2, 3, 4}, fake.fetched)
+}
+
+func TestFindCommentPaginated(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		fake = newFakeComments(commentsPerPage * 7)
+	)
+
+	// Place a comment by the current user on page 5 of 7.
+	target := fake.comments[commentsPerPage*4+42]
+	target.User.Login = github.String("hub-comment-bot")
+	target.Body = github.String("[//]: # (meta:type=default)\n\nold comment")
+
+	client, server := newTestClient(fake)
+	defer server.Close()
+
+	found, err := FindComment(ctx, client, "joshdk", "hub-comment", 123, "hub-comment-bot", "default")
+	assert.Nil(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, target.GetID(), found.GetID())
+	}
+
+	// Pages should be scanned newest-first, without stopping at the matching
+	// page.
+	assert.Equal(t, []int{1, 7, 6, 5, 4, 3, 2}, fake.fetched)
+
+	url, err := UpdateComment(ctx, client, "joshdk", "hub-comment", found.GetID(), "new comment")
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64]string{target.GetID(): "new comment"}, fake.edited)
+	assert.Equal(t, fmt.Sprintf("https://github.com/joshdk/hub-comment/pull/123#issuecomment-%d", target.GetID()), url)
+}
+
+func TestFindCommentUpdatedOnOlderPage(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		fake = newFakeComments(commentsPerPage * 4)
+	)
+
+	// Place a comment by the current user on pages 2 and 3 of 4, where the
+	// comment on the older page was updated more recently.
+	older := fake.comments[commentsPerPage+10]
+	older.User.Login = github.String("hub-comment-bot")
+	updated := fake.comments[len(fake.comments)-1].GetUpdatedAt().Add(time.Hour)
+	older.UpdatedAt = &updated
+
+	newer := fake.comments[commentsPerPage*2+10]
+	newer.User.Login = github.String("hub-comment-bot")
+
+	client, server := newTestClient(fake)
+	defer server.Close()
+
+	found, err := FindComment(ctx, client, "joshdk", "hub-comment", 123, "hub-comment-bot", "default")
+	assert.Nil(t, err)
+	if assert.NotNil(t, found) {
+		assert.Equal(t, older.GetID(), found.GetID())
+	}
+	assert.Equal(t, []int{1, 4, 3, 2}, fake.fetched)
+}
+
+func TestFindCommentMissing(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		fake = newFakeComments(commentsPerPage*2 + 1)
+	)
+
+	client, server := newTestClient(fake)
+	defer server.Close()
+
+	found, err := FindComment(ctx, client, "joshdk", "hub-comment", 123, "hub-comment-bot", "default")
+
+	assert.Nil(t, err)
+	assert.Nil(t, found)
+	assert.Equal(t, []int{1, 3, 2}, fake.fetched)
+}
+
+func TestDeleteComment(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		fake = newFakeComments(3)
+	)
+
+	client, server := newTestClient(fake)
+	defer server.Close()
+
+	err := DeleteComment(ctx, client, "joshdk", "hub-comment", 2)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{2}, fake.deleted)
+}
+
+func TestFindTypedComment(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		fake = newFakeComments(3)
+	)
+
+	// An older typed comment, and a newer untyped comment, both by the
+	// current user.
+	fake.comments[0].User.Login = github.String("hub-comment-bot")
+	fake.comments[0].Body = github.String(`<!-- hub-comment {"v":1,"type":"lint"} -->` + "\n\nold comment")
+	fake.comments[2].User.Login = github.String("hub-comment-bot")
+	fake.comments[2].Body = github.String("Untyped comment")
+
+	client, server := newTestClient(fake)
+	defer server.Close()
+
+	found, err := FindComment(ctx, client, "joshdk", "hub-comment", 123, "hub-comment-bot", "lint")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), found.GetID())
+
+	typed, err := FindTypedComment(ctx, client, "joshdk", "hub-comment", 123, "hub-comment-bot", "lint")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), typed.GetID())
+
+	// Only the typed comment is deleted, and the untyped comment survives.
+	assert.Nil(t, DeleteComment(ctx, client, "joshdk", "hub-comment", typed.GetID()))
+	assert.Equal(t, []int64{1}, fake.deleted)
+
+	missing, err := FindTypedComment(ctx, client, "joshdk", "hub-comment", 123, "hub-comment-bot", "tests")
+	assert.Nil(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestFilterComments(t *testing.T) {
+	tests := []struct {
+		title    string
+		bodies   []string
+		expected int64
+		notFound bool
+	}{
+		{
+			title:    "no comments",
+			notFound: true,
+		},
+		{
+			title:    "other types",
+			bodies:   []string{`<!-- hub-comment {"v":1,"type":"lint"} -->`, "[//]: # (meta:type=tests)"},
+			notFound: true,
+		},
+		{
+			title:    "matching type",
+			bodies:   []string{`<!-- hub-comment {"v":1,"type":"coverage"} -->`, `<!-- hub-comment {"v":1,"type":"lint"} -->`},
+			expected: 1,
+		},
+		{
+			title:    "matching legacy type",
+			bodies:   []string{"[//]: # (meta:type=coverage)\n\nold", `<!-- hub-comment {"v":1,"type":"lint"} -->`},
+			expected: 1,
+		},
+		{
+			title:    "prefix of another type",
+			bodies:   []string{`<!-- hub-comment {"v":1,"type":"coverage-e2e"} -->`, "[//]: # (meta:type=coverage-e2e)"},
+			notFound: true,
+		},
+		{
+			title:    "quoted header",
+			bodies:   []string{"[//]: # (meta:type=lint)\n\n> [//]: # (meta:type=coverage)", "Untyped comment"},
+			expected: 2,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			fake := newFakeComments(len(test.bodies))
+			for index, body := range test.bodies {
+				fake.comments[index].Body = github.String(body)
+				fake.comments[index].User.Login = github.String("hub-comment-bot")
+			}
+
+			id, found := FilterComments(fake.comments, "hub-comment-bot", "coverage")
+			assert.Equal(t, !test.notFound, found)
+			assert.Equal(t, test.expected, id)
+		})
+	}
+}
+
+func TestFindComments(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		fake = newFakeComments(commentsPerPage + 10)
+	)
+
+	for _, index := range []int{3, commentsPerPage + 5, commentsPerPage + 7} {
+		fake.comments[index].User.Login = github.String("hub-comment-bot")
+		fake.comments[index].Body = github.String("[//]: # (meta:type=default)\n\nold comment")
+	}
+	fake.comments[commentsPerPage+5].Body = github.String(`<!-- hub-comment {"v":1,"type":"lint"} -->`)
+
+	// An untyped comment by the same user, like one posted by another tool
+	// sharing the same token, is never hidden.
+	fake.comments[commentsPerPage+9].User.Login = github.String("hub-comment-bot")
+	fake.comments[commentsPerPage+9].Body = github.String("Untyped comment")
+
+	client, server := newTestClient(fake)
+	defer server.Close()
+
+	comments, err := FindComments(ctx, client, "joshdk", "hub-comment", 123, "hub-comment-bot", "default")
+
+	assert.Nil(t, err)
+	if assert.Len(t, comments, 2) {
+		assert.Equal(t, int64(4), comments[0].GetID())
+		assert.Equal(t, int64(commentsPerPage+8), comments[1].GetID())
+	}
+}
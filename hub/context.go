@@ -6,6 +6,9 @@ package hub
 
 import (
 	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"text/template"
@@ -14,35 +17,54 @@ import (
 	"github.com/google/go-github/github"
 )
 
-const (
-	// commentHeader is a line of Markdown to prepend to any posted comment.
-	commentHeader = "[//]: # (meta:type={{.Meta.Type}})"
-
-	// metaTypePrefix is the string to search for inside pre-existing comments
-	// to check if they have a declared type.
-	metaTypePrefix = "[//]: # (meta:type="
-)
-
 // Context represents a logical grouping of data for use with comment templates.
 type Context struct {
-	// Build is a map of CircleCI specific parameters.
+	// Bench is the comparison of the given base and head benchmark results.
+	// Empty if no benchmark results were given.
+	Bench *Benchmarks
+
+	// Build is a map of CI specific parameters.
 	Build map[string]string
 
+	// Coverage is a summary of the given Go coverage profile. Empty if no
+	// coverage profile was given.
+	Coverage *Coverage
+
+	// Data is a map of structured data files, as loaded by LoadData, keyed by
+	// the name each file was given.
+	Data map[string]interface{}
+
 	// Env is a map of available environment variables.
 	Env map[string]string
 
+	// Event is the raw event payload that triggered the current GitHub
+	// Actions workflow. Empty when not running inside of GitHub Actions.
+	Event map[string]interface{}
+
 	// Git is a map of GitHub specific parameters.
 	Git map[string]string
 
 	// Labels is a list of all labels used in the current PR.
 	Labels []string
 
+	// Lint is the combined findings of every lint report given. Empty if no
+	// lint reports were given.
+	Lint *Lint
+
 	// Meta is a map of parameters specific to the internal operation of
 	// hub-comment.
 	Meta map[string]string
 
 	// Pull is a map of parameters specific to the current PR.
 	Pull map[string]string
+
+	// Tests is the combined report of every test result file given. Empty if
+	// no test results were given.
+	Tests *TestReport
+
+	// Vars is a map of variables given on the command line, or read from a
+	// dotenv file.
+	Vars map[string]string
 }
 
 // ContextFuncs represents a logical grouping of text/template functions that
@@ -50,6 +72,20 @@ type Context struct {
 type ContextFuncs struct {
 	// Context is the Context for running functions against.
 	Context *Context
+
+	// Dir is the directory that included files are resolved relative to.
+	Dir string
+
+	// Allowlist restricts the environment variables available to Env. Every
+	// variable is available if nil.
+	Allowlist *Allowlist
+
+	// template is the template set that included files are parsed into.
+	template *template.Template
+
+	// including is the stack of files currently being included, used for
+	// detecting include loops.
+	including []string
 }
 
 // Label returns true if the underlying Context contains the named label.
@@ -62,10 +98,124 @@ func (ctx *ContextFuncs) Label(name string) bool {
 	return false
 }
 
-// makeEnv takes in a list of strings of the form "key=value", and returns a
+// Var returns the value of the named variable from the underlying Context. If
+// the variable is not set, fallback is returned, but only if specified.
+func (ctx *ContextFuncs) Var(name string, fallback ...string) string {
+	return get(ctx.Context.Vars, name, fallback...)
+}
+
+// Env returns the value of the named environment variable from the underlying
+// Context. If an allowlist is set, an error is returned if the variable is not
+// allowed, or is not set.
+func (ctx *ContextFuncs) Env(name string) (string, error) {
+	if !ctx.Allowlist.Allowed(name) {
+		return "", fmt.Errorf("environment variable %q is not allowed by -env-allow", name)
+	}
+	value, found := ctx.Context.Env[name]
+	if !found && ctx.Allowlist != nil {
+		return "", fmt.Errorf("environment variable %q is not set", name)
+	}
+	return value, nil
+}
+
+// Blob returns a link to the given line of the given file, as of the current
+// commit, in the repository being built. An empty string is returned if the
+// repository or commit is not known. Line numbers less than one are ignored.
+func (ctx *ContextFuncs) Blob(file string, line int) string {
+	var (
+		owner = ctx.Context.Build["Owner"]
+		repo  = ctx.Context.Build["Repo"]
+		sha   = ctx.Context.Git["SHA"]
+		host  = hostOf(ctx.Context.Pull["URL"], defaultHost)
+	)
+	if owner == "" || repo == "" || sha == "" {
+		return ""
+	}
+
+	link := fmt.Sprintf("https://%s/%s/%s/blob/%s/%s", host, owner, repo, sha, strings.TrimPrefix(file, "/"))
+	if line > 0 {
+		link += fmt.Sprintf("#L%d", line)
+	}
+	return link
+}
+
+// Findings renders the given lint findings as a Markdown table, with a row for
+// every finding. Each location links to the file and line it refers to, when
+// possible.
+func (ctx *ContextFuncs) Findings(findings []LintFinding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+
+	lines := []string{
+		"| Severity | Location | Rule | Message |",
+		"|----------|----------|------|---------|",
+	}
+	for _, finding := range findings {
+		location := finding.File
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, finding.Line)
+		}
+		location = tableCell("`" + location + "`")
+		if link := ctx.Blob(finding.File, finding.Line); link != "" && finding.File != "" {
+			location = fmt.Sprintf("[%s](%s)", location, link)
+		}
+
+		lines = append(lines, fmt.Sprintf("| %s | %s | %s | %s |",
+			lintIcons[finding.Severity]+" "+finding.Severity,
+			location,
+			tableCell(finding.Rule),
+			tableCell(finding.Message),
+		))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Include renders the named template file with the given data, and returns the
+// result. Relative paths are resolved against the directory of the main
+// template file. Included files may themselves include other files, but not in
+// a loop.
+func (ctx *ContextFuncs) Include(path string, data interface{}) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(ctx.Dir, path)
+	}
+	path = filepath.Clean(path)
+
+	for index, parent := range ctx.including {
+		if parent == path {
+			loop := append(append([]string{}, ctx.including[index:]...), path)
+			return "", fmt.Errorf("include loop: %s", strings.Join(loop, " -> "))
+		}
+	}
+
+	// Parse each included file only once, even if it is included many times.
+	tpl := ctx.template.Lookup(path)
+	if tpl == nil {
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if tpl, err = ctx.template.New(path).Parse(string(body)); err != nil {
+			return "", err
+		}
+	}
+
+	ctx.including = append(ctx.including, path)
+	defer func() {
+		ctx.including = ctx.including[:len(ctx.including)-1]
+	}()
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// MakeEnv takes in a list of strings of the form "key=value", and returns a
 // map of keys to their respective values. Intended to be passed the return
 // value of os.Environ().
-func makeEnv(environ []string) map[string]string {
+func MakeEnv(environ []string) map[string]string {
 	env := make(map[string]string, len(environ))
 
 	for _, entry := range environ {
@@ -90,37 +240,26 @@ func get(env map[string]string, key string, fallback ...string) string {
 	}
 }
 
-// NewContext is a helper for constructing a context object.
-func NewContext(environ []string, issue *github.Issue, typeName string) *Context {
-	var (
-		env    = makeEnv(environ)
-		labels = onlyLabelNames(issue.Labels)
-	)
+// NewContext is a helper for constructing a context object. The Build and Git
+// parameters are populated by the given CI provider from the complete env,
+// while only exposed is made available to templates as Env, so that redacting
+// or filtering variables never hides build information.
+func NewContext(env map[string]string, exposed map[string]string, provider Provider, event map[string]interface{}, issue *github.Issue, typeName string) *Context {
+	labels := onlyLabelNames(issue.Labels)
 
 	return &Context{
-		Build: map[string]string{
-			"CI":       get(env, "CIRCLECI"),
-			"Index":    get(env, "CIRCLE_NODE_INDEX", "0"),
-			"Job":      get(env, "CIRCLE_JOB"),
-			"Nodes":    get(env, "CIRCLE_NODE_TOTAL", "1"),
-			"Number":   get(env, "CIRCLE_BUILD_NUM"),
-			"Owner":    get(env, "CIRCLE_PROJECT_USERNAME"),
-			"Repo":     get(env, "CIRCLE_PROJECT_REPONAME"),
-			"Stage":    get(env, "CIRCLE_STAGE"),
-			"URL":      get(env, "CIRCLE_BUILD_URL"),
-			"User":     get(env, "CIRCLE_USERNAME"),
-			"Workflow": get(env, "CIRCLE_WORKFLOW_ID"),
-		},
-		Env: env,
-		Git: map[string]string{
-			"Branch": get(env, "CIRCLE_BRANCH"),
-			"PR":     get(env, "CIRCLE_PULL_REQUEST"),
-			"SHA":    get(env, "CIRCLE_SHA1"),
-			"Tag":    get(env, "CIRCLE_TAG"),
-		},
-		Labels: labels,
+		Bench:    &Benchmarks{},
+		Build:    provider.Build(env),
+		Coverage: &Coverage{},
+		Data:     map[string]interface{}{},
+		Env:      exposed,
+		Event:    event,
+		Git:      provider.Git(env),
+		Labels:   labels,
+		Lint:     NewLint(nil),
 		Meta: map[string]string{
-			"Type": typeName,
+			"Type":    typeName,
+			"Version": "",
 		},
 		Pull: map[string]string{
 			"Author": issue.GetUser().GetLogin(),
@@ -129,30 +268,91 @@ func NewContext(environ []string, issue *github.Issue, typeName string) *Context
 			"Title":  issue.GetTitle(),
 			"URL":    issue.GetHTMLURL(),
 		},
+		Tests: NewTestReport(nil),
+		Vars:  map[string]string{},
 	}
 }
 
-// NewTemplate is a helper for constructing a template object.
-func NewTemplate(body []byte) (*template.Template, *ContextFuncs, error) {
-	base := trim(string(body))
+// TemplateFile represents the body of a single named template.
+type TemplateFile struct {
+	// Name is used when reporting errors, and for referencing the template
+	// from other templates, like {{template "header.md" .}}.
+	Name string
+
+	// Path is the location of the template file on disk. Empty if the
+	// template was not read from a file.
+	Path string
+
+	// Body is the unparsed template body.
+	Body []byte
+}
+
+// NewTemplate is a helper for constructing a template object. All of the given
+// files are parsed into a single set, so that they can reference each other,
+// and the template named by entrypoint is returned. The first file is used if
+// no entrypoint is given. The comment header is kept separate from the
+// template bodies, so that reported line and column numbers match the original
+// templates.
+func NewTemplate(files []TemplateFile, entrypoint string) (*template.Template, *ContextFuncs, error) {
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("no templates given")
+	}
+
 	cf := &ContextFuncs{}
-	tpl, err := template.New("comment").Funcs(
+	root := template.New(files[0].Name).Funcs(funcs()).Funcs(
 		template.FuncMap{
-			"label": cf.Label,
+			"blob":     cf.Blob,
+			"env":      cf.Env,
+			"findings": cf.Findings,
+			"include":  cf.Include,
+			"label":    cf.Label,
+			"var":      cf.Var,
 		},
-	).Parse(commentHeader + "\n\n" + base)
-	return tpl, cf, err
+	)
+
+	for _, file := range files {
+		if root.Lookup(file.Name) != nil {
+			return nil, nil, fmt.Errorf("template %q given more than once", file.Name)
+		}
+		if _, err := root.New(file.Name).Parse(string(file.Body)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if entrypoint == "" {
+		entrypoint = files[0].Name
+	}
+	tpl := root.Lookup(entrypoint)
+	if tpl == nil {
+		return nil, nil, fmt.Errorf("no template named %q", entrypoint)
+	}
+
+	// Resolve includes relative to the file containing the entrypoint, or to
+	// the first file if the entrypoint was defined with {{define}}.
+	cf.template = root
+	cf.Dir = filepath.Dir(files[0].Path)
+	for _, file := range files {
+		if file.Name == entrypoint && file.Path != "" {
+			cf.Dir = filepath.Dir(file.Path)
+		}
+	}
+
+	return tpl, cf, nil
 }
 
 // Execute applies the given context to the given template and returns the
-// result as a string.
+// result, prefixed with the metadata block, as a string. The metadata block is
+// kept separate from the template, so that reported line and column numbers
+// match the original template.
 func Execute(tpl *template.Template, ctx *Context, ctxfn *ContextFuncs) (string, error) {
-	var buf bytes.Buffer
+	var body bytes.Buffer
 	ctxfn.Context = ctx
-	if err := tpl.Execute(&buf, ctx); err != nil {
+	if err := tpl.Execute(&body, ctx); err != nil {
 		return "", err
 	}
-	return trim(buf.String()), nil
+
+	content := trim(body.String())
+	return trim(NewMetadata(ctx, content).String() + "\n\n" + content), nil
 }
 
 // trim returns the given input string, with all trailing whitespace characters
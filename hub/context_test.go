@@ -6,9 +6,13 @@ package hub
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/google/go-github/github"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -101,3 +105,139 @@ func TestTrim(t *testing.T) {
 		})
 	}
 }
+
+func TestNewTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hub-comment")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"partials/footer.md": "Built by {{.Pull.Author}}.",
+		"partials/nested.md": `Nested: {{include "footer.md" .}}`,
+		"partials/loop-a.md": `{{include "partials/loop-b.md" .}}`,
+		"partials/loop-b.md": `{{include "partials/loop-a.md" .}}`,
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(body), 0644))
+	}
+
+	// file builds a TemplateFile that pretends to live in the temp directory.
+	file := func(name string, body string) TemplateFile {
+		return TemplateFile{Name: name, Path: filepath.Join(dir, name), Body: []byte(body)}
+	}
+
+	tests := []struct {
+		title      string
+		files      []TemplateFile
+		entrypoint string
+		expected   string
+		error      string
+	}{
+		{
+			title: "single template",
+			files: []TemplateFile{
+				file("main.md", "Hello {{.Pull.Author}}!"),
+			},
+			expected: "Hello joshdk!",
+		},
+		{
+			title: "template from another file",
+			files: []TemplateFile{
+				file("main.md", `Hello! {{template "footer.md" .}}`),
+				file("footer.md", "Bye {{.Pull.Author}}!"),
+			},
+			expected: "Hello! Bye joshdk!",
+		},
+		{
+			title: "explicit file entrypoint",
+			files: []TemplateFile{
+				file("main.md", `Hello! {{template "footer.md" .}}`),
+				file("footer.md", "Bye {{.Pull.Author}}!"),
+			},
+			entrypoint: "footer.md",
+			expected:   "Bye joshdk!",
+		},
+		{
+			title: "explicit define entrypoint",
+			files: []TemplateFile{
+				file("main.md", `{{define "short"}}Short {{.Pull.Number}}{{end}}Long`),
+			},
+			entrypoint: "short",
+			expected:   "Short 123",
+		},
+		{
+			title: "missing entrypoint",
+			files: []TemplateFile{
+				file("main.md", "Hello"),
+			},
+			entrypoint: "other.md",
+			error:      `no template named "other.md"`,
+		},
+		{
+			title: "duplicate names",
+			files: []TemplateFile{
+				file("main.md", "Hello"),
+				file("main.md", "Bye"),
+			},
+			error: `template "main.md" given more than once`,
+		},
+		{
+			title: "include relative to main template",
+			files: []TemplateFile{
+				file("main.md", `Hello! {{include "partials/footer.md" .}}`),
+			},
+			expected: "Hello! Built by joshdk.",
+		},
+		{
+			title: "nested include relative to main template",
+			files: []TemplateFile{
+				file("main.md", `{{include "partials/nested.md" .}}`),
+			},
+			error: "open " + filepath.Join(dir, "footer.md") + ": no such file or directory",
+		},
+		{
+			title: "include many times",
+			files: []TemplateFile{
+				file("main.md", `{{include "partials/footer.md" .}} {{with .Pull}}{{include "partials/footer.md" $}}{{end}}`),
+			},
+			expected: "Built by joshdk. Built by joshdk.",
+		},
+		{
+			title: "include loop",
+			files: []TemplateFile{
+				file("main.md", `{{include "partials/loop-a.md" .}}`),
+			},
+			error: "include loop: " + filepath.Join(dir, "partials/loop-a.md") + " -> " + filepath.Join(dir, "partials/loop-b.md") + " -> " + filepath.Join(dir, "partials/loop-a.md"),
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			tpl, cf, err := NewTemplate(test.files, test.entrypoint)
+			if err == nil {
+				issue := &github.Issue{
+					Number: github.Int(123),
+					User:   &github.User{Login: github.String("joshdk")},
+				}
+				ctx := NewContext(nil, map[string]string{}, circleCI{}, nil, issue, "default")
+
+				var actual string
+				if actual, err = Execute(tpl, ctx, cf); err == nil {
+					assert.Equal(t, test.expected, withoutMetadata(t, "default", actual))
+				}
+			}
+
+			if test.error != "" {
+				assert.Error(t, err)
+				if err != nil {
+					assert.Contains(t, err.Error(), test.error)
+				}
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+}
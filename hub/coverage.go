@@ -0,0 +1,162 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Coverage represents a summary of a Go coverage profile.
+type Coverage struct {
+	// Mode is the coverage mode used, one of "set", "count", or "atomic".
+	Mode string
+
+	// Packages is the coverage of each individual package, sorted by name.
+	Packages []PackageCoverage
+
+	// Statements is the total number of statements.
+	Statements int
+
+	// Covered is the total number of statements that were covered.
+	Covered int
+
+	// Total is the percentage of statements that were covered, rounded to
+	// two decimal places.
+	Total float64
+
+	// HasPrevious is true if a previously reported coverage percentage was
+	// found.
+	HasPrevious bool
+
+	// Previous is the previously reported coverage percentage.
+	Previous float64
+
+	// Delta is the change in coverage percentage since the previous report.
+	Delta float64
+}
+
+// PackageCoverage represents the coverage of a single Go package.
+type PackageCoverage struct {
+	// Name is the import path of the package.
+	Name string
+
+	// Statements is the number of statements in the package.
+	Statements int
+
+	// Covered is the number of statements in the package that were covered.
+	Covered int
+
+	// Percent is the percentage of statements that were covered, rounded to
+	// two decimal places.
+	Percent float64
+}
+
+// ReadCoverProfile reads the named Go coverage profile, as written by "go test
+// -coverprofile", and summarizes it by package. Blocks that appear more than
+// once, like when merging several profiles, are counted once, and are covered
+// if any of them were covered.
+func ReadCoverProfile(filename string) (*Coverage, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		coverage = &Coverage{}
+		blocks   = map[string]int{}
+		covered  = map[string]bool{}
+		packages = map[string]*PackageCoverage{}
+	)
+
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "mode: "):
+			coverage.Mode = strings.TrimPrefix(line, "mode: ")
+			continue
+		}
+
+		// Lines look like "example.com/pkg/file.go:12.34,15.2 3 1".
+		fields := strings.Fields(line)
+		if len(fields) != 3 || !strings.Contains(fields[0], ":") {
+			return nil, fmt.Errorf("%s:%d: malformed coverage block %q", filename, number, line)
+		}
+		statements, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("%s:%d: malformed coverage block %q", filename, number, line)
+		}
+
+		block := fields[0]
+		blocks[block] = statements
+		covered[block] = covered[block] || count > 0
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for block, statements := range blocks {
+		name := path.Dir(block[:strings.LastIndex(block, ":")])
+		pkg := packages[name]
+		if pkg == nil {
+			pkg = &PackageCoverage{Name: name}
+			packages[name] = pkg
+		}
+
+		pkg.Statements += statements
+		coverage.Statements += statements
+		if covered[block] {
+			pkg.Covered += statements
+			coverage.Covered += statements
+		}
+	}
+
+	for _, pkg := range packages {
+		pkg.Percent = percent(pkg.Covered, pkg.Statements)
+		coverage.Packages = append(coverage.Packages, *pkg)
+	}
+	sort.Slice(coverage.Packages, func(i, j int) bool {
+		return coverage.Packages[i].Name < coverage.Packages[j].Name
+	})
+	coverage.Total = percent(coverage.Covered, coverage.Statements)
+
+	return coverage, nil
+}
+
+// percent returns the percentage of part in total, rounded to two decimal
+// places.
+func percent(part int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	value, _ := strconv.ParseFloat(strconv.FormatFloat(100*float64(part)/float64(total), 'f', 2, 64), 64)
+	return value
+}
+
+// Compare returns a copy of the coverage, with the change in coverage since
+// the total that was recorded in the metadata of the given comment body. The
+// copy is returned unchanged if the comment did not report any coverage.
+func (c Coverage) Compare(comment string) *Coverage {
+	meta, _, found := ParseComment(comment)
+	if !found || meta.Coverage == nil {
+		return &c
+	}
+
+	previous := *meta.Coverage
+	c.HasPrevious = true
+	c.Previous = previous
+	c.Delta, _ = strconv.ParseFloat(strconv.FormatFloat(c.Total-previous, 'f', 2, 64), 64)
+	return &c
+}
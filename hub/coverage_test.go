@@ -0,0 +1,181 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadCoverProfile(t *testing.T) {
+	tests := []struct {
+		title    string
+		body     string
+		expected *Coverage
+		error    string
+	}{
+		{
+			title:    "empty profile",
+			body:     "mode: set\n",
+			expected: &Coverage{Mode: "set"},
+		},
+		{
+			title: "multiple packages",
+			body: `mode: count
+example.com/app/hub/context.go:10.2,12.3 2 5
+example.com/app/hub/context.go:14.2,16.3 1 0
+example.com/app/hub/pull.go:5.1,9.2 3 1
+example.com/app/main.go:20.2,22.3 4 0
+`,
+			expected: &Coverage{
+				Mode: "count",
+				Packages: []PackageCoverage{
+					{Name: "example.com/app", Statements: 4, Covered: 0, Percent: 0},
+					{Name: "example.com/app/hub", Statements: 6, Covered: 5, Percent: 83.33},
+				},
+				Statements: 10,
+				Covered:    5,
+				Total:      50,
+			},
+		},
+		{
+			title: "merged profiles",
+			body: `mode: set
+example.com/app/main.go:20.2,22.3 4 0
+example.com/app/main.go:24.2,25.3 2 0
+mode: set
+example.com/app/main.go:20.2,22.3 4 1
+`,
+			expected: &Coverage{
+				Mode: "set",
+				Packages: []PackageCoverage{
+					{Name: "example.com/app", Statements: 6, Covered: 4, Percent: 66.67},
+				},
+				Statements: 6,
+				Covered:    4,
+				Total:      66.67,
+			},
+		},
+		{
+			title: "malformed block",
+			body:  "mode: set\nexample.com/app/main.go:20.2,22.3 four 0\n",
+			error: `coverage.out:2: malformed coverage block "example.com/app/main.go:20.2,22.3 four 0"`,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "hub-comment")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "coverage.out")
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			assert.Nil(t, ioutil.WriteFile(path, []byte(test.body), 0644))
+
+			actual, err := ReadCoverProfile(path)
+			if test.error != "" {
+				assert.EqualError(t, err, filepath.Join(dir, test.error))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestCoverageCompare(t *testing.T) {
+	tests := []struct {
+		title       string
+		comment     string
+		hasPrevious bool
+		previous    float64
+		delta       float64
+	}{
+		{
+			title: "no previous comment",
+		},
+		{
+			title:   "previous comment without coverage",
+			comment: "[//]: # (meta:type=coverage)\n\nHello",
+		},
+		{
+			title:   "malformed coverage",
+			comment: "<!-- hub-comment {\"v\":1,\"type\":\"coverage\",\"coverage\":\"lots\"} -->",
+		},
+		{
+			title:       "coverage increased",
+			comment:     "<!-- hub-comment {\"v\":1,\"type\":\"coverage\",\"coverage\":50.7} -->\n\nHello",
+			hasPrevious: true,
+			previous:    50.7,
+			delta:       1.3,
+		},
+		{
+			title:       "metadata block",
+			comment:     "<!-- hub-comment {\"v\":1,\"type\":\"coverage\",\"coverage\":51} -->\n\nHello",
+			hasPrevious: true,
+			previous:    51,
+			delta:       1,
+		},
+		{
+			title:   "quoted metadata",
+			comment: "Hello\n<!-- hub-comment {\"v\":1,\"type\":\"coverage\",\"coverage\":50.7} -->",
+		},
+		{
+			title:       "coverage decreased",
+			comment:     "<!-- hub-comment {\"v\":1,\"type\":\"coverage\",\"coverage\":100} -->",
+			hasPrevious: true,
+			previous:    100,
+			delta:       -48,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			coverage := Coverage{Statements: 100, Covered: 52, Total: 52}
+
+			actual := coverage.Compare(test.comment)
+			assert.Equal(t, test.hasPrevious, actual.HasPrevious)
+			assert.Equal(t, test.previous, actual.Previous)
+			assert.Equal(t, test.delta, actual.Delta)
+			assert.False(t, coverage.HasPrevious)
+		})
+	}
+}
+
+func TestCoverageTemplate(t *testing.T) {
+	coverage := &Coverage{
+		Packages: []PackageCoverage{
+			{Name: "example.com/app", Statements: 4, Covered: 0, Percent: 0},
+			{Name: "example.com/app/hub", Statements: 6, Covered: 5, Percent: 83.33},
+		},
+		Statements: 10,
+		Covered:    5,
+		Total:      50,
+	}
+
+	expected := "### 📊 Coverage is 50.0% (+1.3% vs last build)\n\n" +
+		"5 of 10 statements covered.\n\n" +
+		"| Package | Statements | Coverage |\n" +
+		"|---------|-----------:|---------:|\n" +
+		"| `example.com/app` | 0/4 | 0.0% |\n" +
+		"| `example.com/app/hub` | 5/6 | 83.3% |"
+
+	var state *Context
+	actual := renderBuiltin(t, "coverage", func(ctx *Context) {
+		ctx.Coverage = coverage.Compare(`<!-- hub-comment {"v":1,"type":"coverage","coverage":48.7} -->`)
+		state = ctx
+	})
+	assert.Equal(t, expected, actual)
+
+	// The total coverage is recorded for the next comment to compare against.
+	assert.Equal(t, 50.0, coverage.Compare(NewMetadata(state, actual).String()).Previous)
+}
@@ -0,0 +1,137 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// reIdentifier is a regex intended to match data and variable names that can
+// be referenced directly from a template, like {{.Data.coverage}}.
+var reIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// LoadData reads the given structured data file, and decodes it into a generic
+// value. The format is chosen by the file extension, and may be one of JSON
+// (".json"), YAML (".yaml" or ".yml"), or TOML (".toml").
+func LoadData(path string) (interface{}, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := decodeData(strings.ToLower(filepath.Ext(path)), body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return value, nil
+}
+
+// decodeData decodes the given body, in the format named by the given file
+// extension, into a generic value. Every format is decoded into the same
+// types, so that templates behave the same regardless of the format used.
+// Objects are decoded as map[string]interface{}, lists as []interface{},
+// whole numbers as int64, other numbers as float64, and dates and times as
+// strings.
+func decodeData(ext string, body []byte) (interface{}, error) {
+	var value interface{}
+	switch ext {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		if decoder.More() {
+			return nil, fmt.Errorf("unexpected content after top-level value")
+		}
+	case ".yaml", ".yml":
+		if err := yaml.UnmarshalStrict(body, &value); err != nil {
+			return nil, err
+		}
+	case ".toml":
+		var table map[string]interface{}
+		if err := toml.Unmarshal(body, &table); err != nil {
+			return nil, err
+		}
+		value = table
+	default:
+		return nil, fmt.Errorf("unknown data format %q", ext)
+	}
+
+	return normalizeData(value), nil
+}
+
+// normalizeData converts the given decoded value, and everything inside of
+// it, into the types used for every data format.
+func normalizeData(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeData(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		// YAML allows keys of any type, like numbers.
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeData(item)
+		}
+		return m
+	case []interface{}:
+		for index, item := range v {
+			v[index] = normalizeData(item)
+		}
+		return v
+	case []map[string]interface{}:
+		// TOML decodes arrays of tables with their own type.
+		list := make([]interface{}, len(v))
+		for index, item := range v {
+			list[index] = normalizeData(item)
+		}
+		return list
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case int:
+		return int64(v)
+	case uint64:
+		// YAML decodes integers too large for an int64 as a uint64.
+		if v <= math.MaxInt64 {
+			return int64(v)
+		}
+		return float64(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+// ParseDataFlag splits a flag value of the form "name=path" into its name and
+// path.
+func ParseDataFlag(value string) (string, string, error) {
+	pieces := strings.SplitN(value, "=", 2)
+	switch {
+	case len(pieces) != 2 || pieces[1] == "":
+		return "", "", fmt.Errorf("malformed data %q, must be like name=path", value)
+	case !reIdentifier.MatchString(pieces[0]):
+		return "", "", fmt.Errorf("malformed data name %q", pieces[0])
+	}
+	return pieces[0], pieces[1], nil
+}
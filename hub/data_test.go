@@ -0,0 +1,304 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hub-comment")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	expected := map[string]interface{}{
+		"name":   "unit",
+		"passed": true,
+		"tests":  []interface{}{"a", "b"},
+	}
+
+	tests := []struct {
+		title    string
+		file     string
+		body     string
+		expected interface{}
+		error    string
+	}{
+		{
+			title:    "json",
+			file:     "data.json",
+			body:     `{"name": "unit", "passed": true, "tests": ["a", "b"]}`,
+			expected: expected,
+		},
+		{
+			title:    "yaml",
+			file:     "data.yaml",
+			body:     "name: unit\npassed: true\ntests:\n- a\n- b\n",
+			expected: expected,
+		},
+		{
+			title:    "yml",
+			file:     "data.yml",
+			body:     "name: unit\npassed: true\ntests: [a, b]\n",
+			expected: expected,
+		},
+		{
+			title:    "toml",
+			file:     "data.toml",
+			body:     "name = \"unit\"\npassed = true\ntests = [\"a\", \"b\"]\n",
+			expected: expected,
+		},
+		{
+			title: "unknown format",
+			file:  "data.xml",
+			body:  "<data/>",
+			error: "data.xml: unknown data format \".xml\"",
+		},
+		{
+			title: "malformed json",
+			file:  "broken.json",
+			body:  "{",
+			error: "broken.json: unexpected EOF",
+		},
+		{
+			title: "trailing json",
+			file:  "trailing.json",
+			body:  "{}\n{}",
+			error: "trailing.json: unexpected content after top-level value",
+		},
+		{
+			title: "malformed yaml",
+			file:  "broken.yaml",
+			body:  "a: 1\n  b: 2\n",
+			error: "broken.yaml: yaml: line 2: mapping values are not allowed in this context",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, test.file)
+			assert.Nil(t, ioutil.WriteFile(path, []byte(test.body), 0644))
+
+			actual, err := LoadData(path)
+			if test.error != "" {
+				assert.EqualError(t, err, filepath.Join(dir, test.error))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestParseDataFlag(t *testing.T) {
+	tests := []struct {
+		title string
+		value string
+		name  string
+		path  string
+		error string
+	}{
+		{
+			title: "name and path",
+			value: "coverage=reports/coverage.json",
+			name:  "coverage",
+			path:  "reports/coverage.json",
+		},
+		{
+			title: "path with equals",
+			value: "sizes=out/a=b.json",
+			name:  "sizes",
+			path:  "out/a=b.json",
+		},
+		{
+			title: "missing path",
+			value: "coverage=",
+			error: `malformed data "coverage=", must be like name=path`,
+		},
+		{
+			title: "only path",
+			value: "coverage.json",
+			error: `malformed data "coverage.json", must be like name=path`,
+		},
+		{
+			title: "invalid name",
+			value: "bundle-size=sizes.json",
+			error: `malformed data name "bundle-size"`,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			name, path, err := ParseDataFlag(test.value)
+			if test.error != "" {
+				assert.EqualError(t, err, test.error)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.name, name)
+			assert.Equal(t, test.path, path)
+		})
+	}
+}
+
+func TestDecodeData(t *testing.T) {
+	tests := []struct {
+		title    string
+		ext      string
+		body     string
+		expected interface{}
+		error    string
+	}{
+		{
+			title:    "json numbers",
+			ext:      ".json",
+			body:     `{"int": 42, "neg": -7, "float": 1.5, "whole": 2.0, "big": 1e100}`,
+			expected: map[string]interface{}{"int": int64(42), "neg": int64(-7), "float": 1.5, "whole": 2.0, "big": 1e100},
+		},
+		{
+			title:    "yaml numbers",
+			ext:      ".yaml",
+			body:     "int: 42\nneg: -7\nfloat: 1.5\nwhole: 2.0\nbig: 18446744073709551615\n",
+			expected: map[string]interface{}{"int": int64(42), "neg": int64(-7), "float": 1.5, "whole": 2.0, "big": 18446744073709551615.0},
+		},
+		{
+			title:    "toml numbers",
+			ext:      ".toml",
+			body:     "int = 42\nneg = -7\nfloat = 1.5\nwhole = 2.0\nbig = 1e100\n",
+			expected: map[string]interface{}{"int": int64(42), "neg": int64(-7), "float": 1.5, "whole": 2.0, "big": 1e100},
+		},
+		{
+			title: "yaml nested mappings and sequences",
+			ext:   ".yml",
+			body: `
+# Test results
+suites:
+  - name: unit
+    cases: [ok, ok]
+  - name: e2e
+    cases: []
+defaults: &defaults
+  passed: 3
+totals:
+  <<: *defaults
+  1: one
+`,
+			expected: map[string]interface{}{
+				"suites": []interface{}{
+					map[string]interface{}{"name": "unit", "cases": []interface{}{"ok", "ok"}},
+					map[string]interface{}{"name": "e2e", "cases": []interface{}{}},
+				},
+				"defaults": map[string]interface{}{"passed": int64(3)},
+				"totals":   map[string]interface{}{"passed": int64(3), "1": "one"},
+			},
+		},
+		{
+			title:    "yaml dates",
+			ext:      ".yaml",
+			body:     "date: 2018-09-14\n",
+			expected: map[string]interface{}{"date": "2018-09-14"},
+		},
+		{
+			title: "toml array of tables",
+			ext:   ".toml",
+			body: `
+date = 1979-05-27T07:32:00Z
+
+[[suites]]
+name = "unit"
+
+[suites.totals]
+passed = 2
+
+[[suites]]
+name = "e2e"
+`,
+			expected: map[string]interface{}{
+				"date": "1979-05-27T07:32:00Z",
+				"suites": []interface{}{
+					map[string]interface{}{"name": "unit", "totals": map[string]interface{}{"passed": int64(2)}},
+					map[string]interface{}{"name": "e2e"},
+				},
+			},
+		},
+		{
+			title: "yaml duplicate key",
+			ext:   ".yaml",
+			body:  "a: 1\na: 2\n",
+			error: `yaml: unmarshal errors:
+  line 2: key "a" already set in map`,
+		},
+		{
+			title: "toml duplicate key",
+			ext:   ".toml",
+			body:  "a = 1\na = 2\n",
+			error: "Near line 2 (last key parsed 'a'): Key 'a' has already been defined.",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			actual, err := decodeData(test.ext, []byte(test.body))
+			if test.error != "" {
+				assert.EqualError(t, err, test.error)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestDataTemplate(t *testing.T) {
+	tests := []struct {
+		title string
+		ext   string
+		body  string
+	}{
+		{
+			title: "json",
+			ext:   ".json",
+			body:  `{"count": 0, "ratio": 0.5}`,
+		},
+		{
+			title: "yaml",
+			ext:   ".yaml",
+			body:  "count: 0\nratio: 0.5\n",
+		},
+		{
+			title: "toml",
+			ext:   ".toml",
+			body:  "count = 0\nratio = 0.5\n",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			data, err := decodeData(test.ext, []byte(test.body))
+			assert.Nil(t, err)
+
+			tpl, cf, err := NewTemplate([]TemplateFile{{Name: "comment", Body: []byte(`{{if eq .Data.x.count 0}}none{{end}} {{eq .Data.x.ratio 0.5}}`)}}, "")
+			assert.Nil(t, err)
+
+			ctx := NewContext(nil, map[string]string{}, circleCI{}, nil, &github.Issue{}, "default")
+			ctx.Data["x"] = data
+
+			actual, err := Execute(tpl, ctx, cf)
+			assert.Nil(t, err)
+			assert.Equal(t, "none true", withoutMetadata(t, "default", actual))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+)
+
+// eventPayload represents the subset of fields, common to several GitHub
+// Actions event payloads, that are needed to find the current pull request.
+//
+// See https://docs.github.com/en/developers/webhooks-and-events/webhooks/webhook-events-and-payloads
+type eventPayload struct {
+	// PullRequest is set for "pull_request" and "pull_request_target" events.
+	PullRequest *struct {
+		Number int `json:"number"`
+	} `json:"pull_request"`
+
+	// Issue is set for "issue_comment" events.
+	Issue *struct {
+		Number int `json:"number"`
+
+		// PullRequest is only set if the issue is also a pull request.
+		PullRequest *struct{} `json:"pull_request"`
+	} `json:"issue"`
+
+	// WorkflowRun is set for "workflow_run" events.
+	WorkflowRun *struct {
+		PullRequests []struct {
+			Number int `json:"number"`
+		} `json:"pull_requests"`
+	} `json:"workflow_run"`
+
+	// Repository is set for all repository events.
+	Repository *struct {
+		FullName string `json:"full_name"`
+		HTMLURL  string `json:"html_url"`
+	} `json:"repository"`
+}
+
+// LoadEvent reads the GitHub Actions event payload, named by the
+// GITHUB_EVENT_PATH environment variable, into a generic map. An empty map is
+// returned if no event payload exists.
+func LoadEvent(env map[string]string) (map[string]interface{}, error) {
+	event := map[string]interface{}{}
+
+	path := get(env, "GITHUB_EVENT_PATH")
+	if path == "" {
+		return event, nil
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// eventReference returns a link to the pull request described by the given
+// GitHub Actions event payload. Only the "pull_request",
+// "pull_request_target", "issue_comment", and "workflow_run" events are
+// supported.
+func eventReference(name string, body []byte, server string) (string, bool) {
+	var payload eventPayload
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Repository == nil {
+		return "", false
+	}
+
+	var number int
+	switch name {
+	case "pull_request", "pull_request_target":
+		if payload.PullRequest != nil {
+			number = payload.PullRequest.Number
+		}
+	case "issue_comment":
+		// Comments left on plain issues do not have an associated pull
+		// request.
+		if payload.Issue != nil && payload.Issue.PullRequest != nil {
+			number = payload.Issue.Number
+		}
+	case "workflow_run":
+		// A workflow run may be associated with several pull requests. Only
+		// the first is used.
+		if payload.WorkflowRun != nil && len(payload.WorkflowRun.PullRequests) > 0 {
+			number = payload.WorkflowRun.PullRequests[0].Number
+		}
+	}
+
+	var (
+		host        = hostOf(first(payload.Repository.HTMLURL, server), "github.com")
+		owner, repo = splitSlug(payload.Repository.FullName)
+	)
+	return reference(pullLink(host, owner, repo, strconv.Itoa(number)))
+}
@@ -0,0 +1,118 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventReference(t *testing.T) {
+	tests := []struct {
+		title     string
+		name      string
+		body      string
+		server    string
+		reference string
+	}{
+		{
+			title: "malformed payload",
+			name:  "pull_request",
+			body:  `{`,
+		},
+		{
+			title: "missing repository",
+			name:  "pull_request",
+			body:  `{"pull_request": {"number": 123}}`,
+		},
+		{
+			title:     "pull request",
+			name:      "pull_request",
+			body:      `{"pull_request": {"number": 123}, "repository": {"full_name": "joshdk/hub-comment", "html_url": "https://github.com/joshdk/hub-comment"}}`,
+			reference: "https://github.com/joshdk/hub-comment/pull/123",
+		},
+		{
+			title:     "pull request target",
+			name:      "pull_request_target",
+			body:      `{"pull_request": {"number": 123}, "repository": {"full_name": "joshdk/hub-comment"}}`,
+			server:    "https://github.example.com",
+			reference: "https://github.example.com/joshdk/hub-comment/pull/123",
+		},
+		{
+			title:     "issue comment on pull request",
+			name:      "issue_comment",
+			body:      `{"issue": {"number": 123, "pull_request": {}}, "repository": {"full_name": "joshdk/hub-comment"}}`,
+			reference: "https://github.com/joshdk/hub-comment/pull/123",
+		},
+		{
+			title: "issue comment on issue",
+			name:  "issue_comment",
+			body:  `{"issue": {"number": 123}, "repository": {"full_name": "joshdk/hub-comment"}}`,
+		},
+		{
+			title:     "workflow run",
+			name:      "workflow_run",
+			body:      `{"workflow_run": {"pull_requests": [{"number": 123}, {"number": 456}]}, "repository": {"full_name": "joshdk/hub-comment"}}`,
+			reference: "https://github.com/joshdk/hub-comment/pull/123",
+		},
+		{
+			title: "workflow run without pull requests",
+			name:  "workflow_run",
+			body:  `{"workflow_run": {"pull_requests": []}, "repository": {"full_name": "joshdk/hub-comment"}}`,
+		},
+		{
+			title: "push",
+			name:  "push",
+			body:  `{"ref": "refs/heads/master", "repository": {"full_name": "joshdk/hub-comment"}}`,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			reference, found := eventReference(test.name, []byte(test.body), test.server)
+
+			assert.Equal(t, test.reference, reference)
+			assert.Equal(t, test.reference != "", found)
+		})
+	}
+}
+
+func TestLoadEvent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hub-comment")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		path = filepath.Join(dir, "event.json")
+		body = `{"pull_request": {"number": 123, "head": {"ref": "feature"}}, "repository": {"full_name": "joshdk/hub-comment"}, "sender": {"login": "joshdk"}}`
+		env  = map[string]string{
+			"GITHUB_ACTIONS":    "true",
+			"GITHUB_EVENT_NAME": "pull_request",
+			"GITHUB_EVENT_PATH": path,
+			"GITHUB_REF":        "refs/heads/feature",
+		}
+	)
+	if err := ioutil.WriteFile(path, []byte(body), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := LoadEvent(env)
+	assert.Nil(t, err)
+	assert.Equal(t, "joshdk", event["sender"].(map[string]interface{})["login"])
+
+	reference, found := DetectProvider(env).Reference(env)
+	assert.True(t, found)
+	assert.Equal(t, "https://github.com/joshdk/hub-comment/pull/123", reference)
+
+	event, err = LoadEvent(map[string]string{})
+	assert.Nil(t, err)
+	assert.Empty(t, event)
+}
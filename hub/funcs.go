@@ -0,0 +1,464 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+)
+
+// funcs returns the library of general purpose functions available to every
+// template. Functions that take a value to operate on always take it as their
+// last argument, so that they can be used at the end of a pipeline, like
+// {{.Pull.Title | truncate 40}}.
+func funcs() template.FuncMap {
+	return template.FuncMap{
+		// String functions.
+		"contains":   func(substr string, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix string, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix string, s string) bool { return strings.HasSuffix(s, suffix) },
+		"indent":     indent,
+		"join":       join,
+		"lower":      strings.ToLower,
+		"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+		"plural":     plural,
+		"quote":      strconv.Quote,
+		"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+		"replace":    func(old string, new string, s string) string { return strings.Replace(s, old, new, -1) },
+		"split":      func(sep string, s string) []string { return strings.Split(s, sep) },
+		"title":      strings.Title,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix string, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix string, s string) string { return strings.TrimSuffix(s, suffix) },
+		"truncate":   truncate,
+		"upper":      strings.ToUpper,
+
+		// Default functions.
+		"coalesce": coalesce,
+		"default":  func(fallback interface{}, value interface{}) interface{} { return coalesce(value, fallback) },
+		"empty":    empty,
+
+		// Math functions.
+		"add":   func(a interface{}, b interface{}) (interface{}, error) { return arithmetic(a, b, "add") },
+		"sub":   func(a interface{}, b interface{}) (interface{}, error) { return arithmetic(a, b, "sub") },
+		"mul":   func(a interface{}, b interface{}) (interface{}, error) { return arithmetic(a, b, "mul") },
+		"div":   func(a interface{}, b interface{}) (interface{}, error) { return arithmetic(a, b, "div") },
+		"mod":   func(a interface{}, b interface{}) (interface{}, error) { return arithmetic(a, b, "mod") },
+		"max":   func(a interface{}, b interface{}) (interface{}, error) { return arithmetic(a, b, "max") },
+		"min":   func(a interface{}, b interface{}) (interface{}, error) { return arithmetic(a, b, "min") },
+		"round": round,
+		"float": toFloat,
+		"int":   toInt,
+
+		// Date and duration functions.
+		"date":     date,
+		"duration": duration,
+		"now":      time.Now,
+		"since":    since,
+
+		// Encoding functions.
+		"fromJson":     fromJSON,
+		"toJson":       toJSON,
+		"toPrettyJson": toPrettyJSON,
+		"toYaml":       toYAML,
+
+		// Markdown functions.
+		"details":   details,
+		"tableCell": tableCell,
+
+		// Regex functions.
+		"regexFind":    regexFind,
+		"regexMatch":   regexMatch,
+		"regexReplace": regexReplace,
+	}
+}
+
+// indent prefixes every line of s with the given number of spaces.
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+}
+
+// join concatenates the elements of list, which may be a slice of any type,
+// separated by sep.
+func join(sep string, list interface{}) (string, error) {
+	value := reflect.ValueOf(list)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+	default:
+		return "", fmt.Errorf("join: cannot join value of type %T", list)
+	}
+
+	pieces := make([]string, value.Len())
+	for index := range pieces {
+		pieces[index] = fmt.Sprint(value.Index(index).Interface())
+	}
+	return strings.Join(pieces, sep), nil
+}
+
+// truncate shortens s to at most length characters, replacing the final
+// character with an ellipsis if anything was removed.
+func truncate(length int, s string) string {
+	if length <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:length-1]) + "…"
+}
+
+// plural formats count followed by either the singular or the plural form of
+// a noun, like "1 error" or "3 errors".
+func plural(singular string, plural string, count interface{}) (string, error) {
+	n, err := toInt(count)
+	if err != nil {
+		return "", fmt.Errorf("plural: %v", err)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular), nil
+	}
+	return fmt.Sprintf("%d %s", n, plural), nil
+}
+
+// empty returns true if the given value is the zero value for its type, or is
+// an empty slice or map.
+func empty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return reflect.DeepEqual(value, reflect.Zero(rv.Type()).Interface())
+	}
+}
+
+// coalesce returns the first of the given values that is not empty.
+func coalesce(values ...interface{}) interface{} {
+	for _, value := range values {
+		if !empty(value) {
+			return value
+		}
+	}
+	return nil
+}
+
+// number converts the given value, which may be any integer, float, or numeric
+// string, into either an int64 or a float64.
+func number(value interface{}) (int64, float64, bool, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), 0, true, nil
+	case int64:
+		return v, 0, true, nil
+	case int32:
+		return int64(v), 0, true, nil
+	case float64:
+		return 0, v, false, nil
+	case float32:
+		return 0, float64(v), false, nil
+	case time.Duration:
+		return int64(v), 0, true, nil
+	case string:
+		if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return i, 0, true, nil
+		}
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return 0, f, false, nil
+		}
+	}
+	return 0, 0, false, fmt.Errorf("cannot use %#v as a number", value)
+}
+
+// toInt converts the given value into an int64, truncating any fraction.
+func toInt(value interface{}) (int64, error) {
+	i, f, isInt, err := number(value)
+	if err != nil || isInt {
+		return i, err
+	}
+	return int64(f), nil
+}
+
+// toFloat converts the given value into a float64.
+func toFloat(value interface{}) (float64, error) {
+	i, f, isInt, err := number(value)
+	if err != nil || !isInt {
+		return f, err
+	}
+	return float64(i), nil
+}
+
+// arithmetic applies the named operation to a and b. Integer arithmetic is
+// used if both values are integers, otherwise floating point arithmetic is
+// used.
+func arithmetic(a interface{}, b interface{}, op string) (interface{}, error) {
+	ai, af, aInt, err := number(a)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	bi, bf, bInt, err := number(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	if aInt && bInt {
+		switch op {
+		case "add":
+			return ai + bi, nil
+		case "sub":
+			return ai - bi, nil
+		case "mul":
+			return ai * bi, nil
+		case "div", "mod":
+			if bi == 0 {
+				return nil, fmt.Errorf("%s: division by zero", op)
+			}
+			if op == "div" {
+				return ai / bi, nil
+			}
+			return ai % bi, nil
+		case "max":
+			if ai > bi {
+				return ai, nil
+			}
+			return bi, nil
+		default:
+			if ai < bi {
+				return ai, nil
+			}
+			return bi, nil
+		}
+	}
+
+	if aInt {
+		af = float64(ai)
+	}
+	if bInt {
+		bf = float64(bi)
+	}
+
+	switch op {
+	case "add":
+		return af + bf, nil
+	case "sub":
+		return af - bf, nil
+	case "mul":
+		return af * bf, nil
+	case "div":
+		if bf == 0 {
+			return nil, fmt.Errorf("%s: division by zero", op)
+		}
+		return af / bf, nil
+	case "mod":
+		if bf == 0 {
+			return nil, fmt.Errorf("%s: division by zero", op)
+		}
+		return math.Mod(af, bf), nil
+	case "max":
+		return math.Max(af, bf), nil
+	default:
+		return math.Min(af, bf), nil
+	}
+}
+
+// round rounds the given value to the given number of decimal places.
+func round(places int, value interface{}) (float64, error) {
+	f, err := toFloat(value)
+	if err != nil {
+		return 0, fmt.Errorf("round: %v", err)
+	}
+	scale := math.Pow(10, float64(places))
+	return math.Round(f*scale) / scale, nil
+}
+
+// toTime converts the given value, which may be a time.Time, an RFC 3339
+// string, or a number of seconds since the Unix epoch, into a time.Time.
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v != nil {
+			return *v, nil
+		}
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, nil
+		}
+	}
+
+	if seconds, err := toInt(value); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot use %#v as a time", value)
+}
+
+// date formats the given time, using the given layout. The layout is either a
+// Go reference time layout, or one of the names "RFC3339", "date", "time", or
+// "datetime".
+func date(layout string, value interface{}) (string, error) {
+	t, err := toTime(value)
+	if err != nil {
+		return "", fmt.Errorf("date: %v", err)
+	}
+
+	switch layout {
+	case "RFC3339":
+		layout = time.RFC3339
+	case "date":
+		layout = "2006-01-02"
+	case "time":
+		layout = "15:04:05"
+	case "datetime":
+		layout = "2006-01-02 15:04:05"
+	}
+	return t.Format(layout), nil
+}
+
+// duration converts the given value, which may be a time.Duration, a Go
+// duration string, or a number of seconds, into a time.Duration. Durations are
+// rendered like "1h2m3s".
+func duration(value interface{}) (time.Duration, error) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, nil
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d, nil
+		}
+	}
+
+	seconds, err := toFloat(value)
+	if err != nil {
+		return 0, fmt.Errorf("duration: cannot use %#v as a duration", value)
+	}
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond), nil
+}
+
+// since returns the time elapsed since the given time, rounded to the second.
+func since(value interface{}) (time.Duration, error) {
+	t, err := toTime(value)
+	if err != nil {
+		return 0, fmt.Errorf("since: %v", err)
+	}
+	return time.Since(t).Round(time.Second), nil
+}
+
+// toJSON encodes the given value as compact JSON.
+func toJSON(value interface{}) (string, error) {
+	body, err := json.Marshal(value)
+	return string(body), err
+}
+
+// toPrettyJSON encodes the given value as indented JSON.
+func toPrettyJSON(value interface{}) (string, error) {
+	body, err := json.MarshalIndent(value, "", "  ")
+	return string(body), err
+}
+
+// fromJSON decodes the given JSON string into a generic value.
+func fromJSON(s string) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(s), &value); err != nil {
+		return nil, fmt.Errorf("fromJson: %v", err)
+	}
+	return value, nil
+}
+
+// toYAML encodes the given value as block style YAML. The value is first
+// round-tripped through JSON, so that any type which can be encoded as JSON can
+// also be encoded as YAML, using the same field names.
+func toYAML(value interface{}) (string, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(body, &generic); err != nil {
+		return "", err
+	}
+
+	body, err = yaml.Marshal(generic)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(body), "\n"), nil
+}
+
+// details renders a collapsible section with the given summary, and with body
+// shown as a code block.
+func details(summary string, body string) string {
+	// Use a code fence that is longer than any run of backticks in the body.
+	fence := "```"
+	for strings.Contains(body, fence) {
+		fence += "`"
+	}
+
+	return "<details>\n<summary>" + html.EscapeString(summary) + "</summary>\n\n" +
+		fence + "\n" + strings.TrimRight(body, "\n") + "\n" + fence + "\n</details>"
+}
+
+// tableCell escapes s for use inside of a single Markdown table cell.
+func tableCell(s string) string {
+	s = strings.Replace(strings.TrimSpace(s), "|", "\\|", -1)
+	return strings.Replace(strings.Replace(s, "\r\n", "\n", -1), "\n", "<br>", -1)
+}
+
+// compileRegex compiles the given regex, naming the calling function in any
+// error.
+func compileRegex(name string, pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return re, nil
+}
+
+// regexMatch returns true if s contains a match of the given regex.
+func regexMatch(pattern string, s string) (bool, error) {
+	re, err := compileRegex("regexMatch", pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s), nil
+}
+
+// regexFind returns the first match of the given regex in s.
+func regexFind(pattern string, s string) (string, error) {
+	re, err := compileRegex("regexFind", pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.FindString(s), nil
+}
+
+// regexReplace replaces every match of the given regex in s with repl. The
+// replacement may refer to submatches like "$1".
+func regexReplace(pattern string, repl string, s string) (string, error) {
+	re, err := compileRegex("regexReplace", pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.ReplaceAllString(s, repl), nil
+}
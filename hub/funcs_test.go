@@ -0,0 +1,150 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestFuncs(t *testing.T) {
+	data := map[string]interface{}{
+		"Empty":   "",
+		"List":    []string{"a", "b", "c"},
+		"Map":     map[string]interface{}{"name": "hub-comment", "tags": []string{"ci", "github"}, "stars": 42, "nested": map[string]interface{}{"ok": true, "none": nil}},
+		"Number":  "42",
+		"Seconds": 3723.5,
+		"Text":    "Hello, World",
+		"Time":    time.Date(2018, 9, 14, 12, 30, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		title    string
+		body     string
+		expected string
+		error    string
+	}{
+		// String functions.
+		{title: "upper", body: `{{.Text | upper}}`, expected: "HELLO, WORLD"},
+		{title: "lower", body: `{{.Text | lower}}`, expected: "hello, world"},
+		{title: "title", body: `{{"hello world" | title}}`, expected: "Hello World"},
+		{title: "trim", body: `{{"  padded  " | trim}}`, expected: "padded"},
+		{title: "trimPrefix", body: `{{.Text | trimPrefix "Hello, "}}`, expected: "World"},
+		{title: "trimSuffix", body: `{{"main.go" | trimSuffix ".go"}}`, expected: "main"},
+		{title: "replace", body: `{{.Text | replace "o" "0"}}`, expected: "Hell0, W0rld"},
+		{title: "split", body: `{{index ("a,b,c" | split ",") 1}}`, expected: "b"},
+		{title: "join", body: `{{.List | join ", "}}`, expected: "a, b, c"},
+		{title: "join split", body: `{{"a-b-c" | split "-" | join "+"}}`, expected: "a+b+c"},
+		{title: "join invalid", body: `{{.Text | join ", "}}`, error: "join: cannot join value of type string"},
+		{title: "contains", body: `{{if .Text | contains "World"}}yes{{end}}`, expected: "yes"},
+		{title: "hasPrefix", body: `{{if .Text | hasPrefix "Hello"}}yes{{end}}`, expected: "yes"},
+		{title: "hasSuffix", body: `{{if .Text | hasSuffix "Hello"}}yes{{else}}no{{end}}`, expected: "no"},
+		{title: "repeat", body: `{{"=" | repeat 5}}`, expected: "====="},
+		{title: "quote", body: `{{.Text | quote}}`, expected: `"Hello, World"`},
+		{title: "indent", body: `{{"a\nb" | indent 2}}`, expected: "  a\n  b"},
+		{title: "nindent", body: `x:{{"a\nb" | nindent 2}}`, expected: "x:\n  a\n  b"},
+		{title: "truncate", body: `{{.Text | truncate 8}}`, expected: "Hello, …"},
+		{title: "truncate short", body: `{{.Text | truncate 50}}`, expected: "Hello, World"},
+		{title: "plural one", body: `{{1 | plural "error" "errors"}}`, expected: "1 error"},
+		{title: "plural many", body: `{{plural "finding" "findings" 3}}`, expected: "3 findings"},
+		{title: "plural zero", body: `{{0 | plural "note" "notes"}}`, expected: "0 notes"},
+		{title: "plural invalid", body: `{{.Text | plural "a" "b"}}`, error: `plural: cannot use "Hello, World" as a number`},
+		{title: "truncate unicode", body: `{{"🤖🤖🤖🤖" | truncate 3}}`, expected: "🤖🤖…"},
+
+		// Default functions.
+		{title: "default empty", body: `{{.Empty | default "n/a"}}`, expected: "n/a"},
+		{title: "default present", body: `{{.Text | default "n/a"}}`, expected: "Hello, World"},
+		{title: "coalesce", body: `{{coalesce .Empty .Missing .Number}}`, expected: "42"},
+		{title: "empty", body: `{{if empty .Empty}}yes{{end}}`, expected: "yes"},
+
+		// Math functions.
+		{title: "add strings", body: `{{add .Number 8}}`, expected: "50"},
+		{title: "sub", body: `{{sub 10 .Number}}`, expected: "-32"},
+		{title: "mul float", body: `{{mul 1.5 .Number}}`, expected: "63"},
+		{title: "div int", body: `{{div 7 2}}`, expected: "3"},
+		{title: "div float", body: `{{div 7.0 2}}`, expected: "3.5"},
+		{title: "div zero", body: `{{div 7 0}}`, error: "div: division by zero"},
+		{title: "mod", body: `{{mod 7 4}}`, expected: "3"},
+		{title: "max", body: `{{max 3 .Number}}`, expected: "42"},
+		{title: "min", body: `{{min 3 .Number}}`, expected: "3"},
+		{title: "round", body: `{{round 2 3.14159}}`, expected: "3.14"},
+		{title: "int", body: `{{int "3.9"}}`, expected: "3"},
+		{title: "float", body: `{{float "3"}}`, expected: "3"},
+		{title: "non-number", body: `{{add .Text 1}}`, error: `add: cannot use "Hello, World" as a number`},
+
+		// Date and duration functions.
+		{title: "date layout", body: `{{.Time | date "Jan 2, 2006"}}`, expected: "Sep 14, 2018"},
+		{title: "date named", body: `{{.Time | date "datetime"}}`, expected: "2018-09-14 12:30:00"},
+		{title: "date string", body: `{{"2018-09-14T12:30:00Z" | date "date"}}`, expected: "2018-09-14"},
+		{title: "date unix", body: `{{1536928200 | date "RFC3339"}}`, expected: "2018-09-14T12:30:00Z"},
+		{title: "duration seconds", body: `{{.Seconds | duration}}`, expected: "1h2m3.5s"},
+		{title: "duration string", body: `{{"90m" | duration}}`, expected: "1h30m0s"},
+		{title: "duration invalid", body: `{{"soon" | duration}}`, error: `duration: cannot use "soon" as a duration`},
+
+		// Encoding functions.
+		{title: "toJson", body: `{{.List | toJson}}`, expected: `["a","b","c"]`},
+		{title: "toPrettyJson", body: `{{.List | toPrettyJson}}`, expected: "[\n  \"a\",\n  \"b\",\n  \"c\"\n]"},
+		{title: "fromJson", body: `{{(fromJson "{\"a\": {\"b\": 1}}").a.b}}`, expected: "1"},
+		{title: "fromJson invalid", body: `{{fromJson "{"}}`, error: "fromJson: unexpected end of JSON input"},
+		{title: "toYaml", body: `{{.Map | toYaml}}`, expected: "name: hub-comment\nnested:\n  none: null\n  ok: true\nstars: 42\ntags:\n- ci\n- github"},
+		{title: "toYaml quoting", body: `{{.Text | toYaml}} {{"yes" | toYaml}} {{"123" | toYaml}}`, expected: `Hello, World "yes" "123"`},
+
+		// Markdown functions.
+		{title: "details", body: `{{"panic: oops" | details "TestMain"}}`, expected: "<details>\n<summary>TestMain</summary>\n\n```\npanic: oops\n```\n</details>"},
+		{title: "details escaping", body: "{{\"a ```fence```\" | details \"<b>\"}}", expected: "<details>\n<summary>&lt;b&gt;</summary>\n\n````\na ```fence```\n````\n</details>"},
+		{title: "tableCell", body: `{{"a | b\nc " | tableCell}}`, expected: "a \\| b<br>c"},
+
+		// Regex functions.
+		{title: "regexMatch", body: `{{if .Text | regexMatch "^H.*d$"}}yes{{end}}`, expected: "yes"},
+		{title: "regexFind", body: `{{"build-1234-final" | regexFind "[0-9]+"}}`, expected: "1234"},
+		{title: "regexReplace", body: `{{.Text | regexReplace "(\\w+), (\\w+)" "$2 $1"}}`, expected: "World Hello"},
+		{title: "regex invalid", body: `{{.Text | regexMatch "("}}`, error: "regexMatch: error parsing regexp: missing closing ): `(`"},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			tpl, err := template.New("test").Funcs(funcs()).Parse(test.body)
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			var buf bytes.Buffer
+			err = tpl.Execute(&buf, data)
+
+			if test.error != "" {
+				if assert.NotNil(t, err) {
+					assert.Contains(t, err.Error(), test.error)
+				}
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestToYAMLRoundTrip(t *testing.T) {
+	tests := []string{"y", "n", "on", "off", "null", "1e3", "yes", "~", "true", "0x10", "Hello, World", ""}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %q", index+1, test)
+		t.Run(name, func(t *testing.T) {
+			body, err := toYAML(map[string]interface{}{"value": test})
+			assert.Nil(t, err)
+
+			// Strings must never be read back as a different type.
+			var actual map[string]interface{}
+			assert.Nil(t, yaml.Unmarshal([]byte(body), &actual))
+			assert.Equal(t, map[string]interface{}{"value": test}, actual)
+		})
+	}
+}
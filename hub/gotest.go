@@ -0,0 +1,171 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// goTestPackageCase is the name given to the synthetic test case used to
+// report a package that failed outside of any test, like one that failed to
+// build.
+const goTestPackageCase = "(package)"
+
+// goTestEvent represents a single line of output from "go test -json".
+//
+// See https://pkg.go.dev/cmd/test2json
+type goTestEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+
+	// OutputType is "error" for lines written by t.Error or t.Fatal. Only
+	// set by newer versions of Go.
+	OutputType string `json:"OutputType"`
+}
+
+// goTestResult accumulates the events for a single package or test.
+type goTestResult struct {
+	status  string
+	elapsed time.Duration
+	output  []string
+
+	// message is the first line of output written by t.Error or t.Fatal.
+	message string
+}
+
+// ReadGoTest reads the output of "go test -json", and returns a test suite for
+// every package. Lines that are not JSON, like build errors printed to stderr,
+// are ignored. The captured output of every failed test is kept.
+func ReadGoTest(r io.Reader) ([]TestSuite, error) {
+	var (
+		packages = map[string]*goTestResult{}
+		tests    = map[string]map[string]*goTestResult{}
+		order    []string
+		names    = map[string][]string{}
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var event goTestEvent
+		if line := strings.TrimSpace(scanner.Text()); !strings.HasPrefix(line, "{") {
+			continue
+		} else if err := json.Unmarshal([]byte(line), &event); err != nil || event.Package == "" {
+			continue
+		}
+
+		if _, found := packages[event.Package]; !found {
+			packages[event.Package] = &goTestResult{}
+			tests[event.Package] = map[string]*goTestResult{}
+			order = append(order, event.Package)
+		}
+
+		result := packages[event.Package]
+		if event.Test != "" {
+			if result = tests[event.Package][event.Test]; result == nil {
+				result = &goTestResult{}
+				tests[event.Package][event.Test] = result
+				names[event.Package] = append(names[event.Package], event.Test)
+			}
+		}
+
+		switch event.Action {
+		case "output":
+			result.output = append(result.output, event.Output)
+			if event.OutputType == "error" && result.message == "" {
+				result.message = strings.TrimSpace(event.Output)
+			}
+		case "pass":
+			result.status = TestPassed
+		case "fail":
+			result.status = TestFailed
+		case "skip":
+			result.status = TestSkipped
+		}
+		if event.Elapsed > 0 {
+			result.elapsed = time.Duration(event.Elapsed * float64(time.Second))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	suites := make([]TestSuite, 0, len(order))
+	for _, pkg := range order {
+		suite := TestSuite{
+			Name:     pkg,
+			Duration: packages[pkg].elapsed,
+		}
+
+		failed := false
+		for _, name := range names[pkg] {
+			result := tests[pkg][name]
+			tc := TestCase{
+				Suite:    pkg,
+				Name:     name,
+				Status:   result.status,
+				Duration: result.elapsed,
+			}
+
+			switch result.status {
+			case "":
+				// Tests that never finished, like those interrupted by a
+				// panic or timeout.
+				tc.Status = TestErrored
+				tc.Message = "test did not finish"
+				tc.Output = goTestOutput(result.output)
+			case TestFailed:
+				tc.Output = goTestOutput(result.output)
+				tc.Message = first(result.message, firstLine(tc.Output))
+			case TestSkipped:
+				tc.Message = firstLine(goTestOutput(result.output))
+			}
+
+			failed = failed || tc.Failed()
+			suite.Cases = append(suite.Cases, tc)
+		}
+
+		// Packages can fail without any failing test, like when a package
+		// fails to build, or when TestMain exits early.
+		if packages[pkg].status == TestFailed && !failed {
+			output := goTestOutput(packages[pkg].output)
+			suite.Cases = append(suite.Cases, TestCase{
+				Suite:   pkg,
+				Name:    goTestPackageCase,
+				Status:  TestErrored,
+				Message: first(firstLine(output), "package failed"),
+				Output:  output,
+			})
+		}
+
+		suites = append(suites, suite)
+	}
+	return suites, nil
+}
+
+// goTestOutput joins the captured output of a test, dropping the status lines
+// added by the test framework itself, like "=== RUN" and "--- FAIL".
+func goTestOutput(lines []string) string {
+	var kept []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "=== "),
+			strings.HasPrefix(trimmed, "--- "),
+			trimmed == "FAIL", trimmed == "PASS",
+			strings.HasPrefix(trimmed, "FAIL\t"), strings.HasPrefix(trimmed, "ok  \t"):
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, "\n"))
+	}
+	return strings.Trim(strings.Join(kept, "\n"), "\n")
+}
@@ -0,0 +1,164 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadGoTest(t *testing.T) {
+	tests := []struct {
+		title    string
+		body     string
+		expected []TestSuite
+	}{
+		{
+			title:    "empty",
+			expected: []TestSuite{},
+		},
+		{
+			title: "passing, failing, and skipped tests",
+			body: `{"Action":"start","Package":"example.com/p"}
+{"Action":"run","Package":"example.com/p","Test":"TestPass"}
+{"Action":"output","Package":"example.com/p","Test":"TestPass","Output":"=== RUN   TestPass\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/p","Test":"TestPass","Output":"--- PASS: TestPass (0.25s)\n","OutputType":"frame"}
+{"Action":"pass","Package":"example.com/p","Test":"TestPass","Elapsed":0.25}
+{"Action":"run","Package":"example.com/p","Test":"TestFail"}
+{"Action":"output","Package":"example.com/p","Test":"TestFail","Output":"=== RUN   TestFail\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/p","Test":"TestFail","Output":"    p_test.go:4: context\n"}
+{"Action":"output","Package":"example.com/p","Test":"TestFail","Output":"    p_test.go:4: expected 1, got 2\n","OutputType":"error"}
+{"Action":"output","Package":"example.com/p","Test":"TestFail","Output":"--- FAIL: TestFail (0.00s)\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/p","Test":"TestFail","Elapsed":0}
+{"Action":"run","Package":"example.com/p","Test":"TestSkip"}
+{"Action":"output","Package":"example.com/p","Test":"TestSkip","Output":"=== RUN   TestSkip\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/p","Test":"TestSkip","Output":"    p_test.go:5: later\n"}
+{"Action":"output","Package":"example.com/p","Test":"TestSkip","Output":"--- SKIP: TestSkip (0.00s)\n","OutputType":"frame"}
+{"Action":"skip","Package":"example.com/p","Test":"TestSkip","Elapsed":0}
+{"Action":"output","Package":"example.com/p","Output":"FAIL\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/p","Output":"FAIL\texample.com/p\t0.5s\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/p","Elapsed":0.5}
+`,
+			expected: []TestSuite{{
+				Name:     "example.com/p",
+				Duration: 500 * time.Millisecond,
+				Cases: []TestCase{
+					{Suite: "example.com/p", Name: "TestPass", Status: TestPassed, Duration: 250 * time.Millisecond},
+					{Suite: "example.com/p", Name: "TestFail", Status: TestFailed, Message: "p_test.go:4: expected 1, got 2", Output: "    p_test.go:4: context\n    p_test.go:4: expected 1, got 2"},
+					{Suite: "example.com/p", Name: "TestSkip", Status: TestSkipped, Message: "p_test.go:5: later"},
+				},
+			}},
+		},
+		{
+			title: "unfinished test",
+			body: `{"Action":"run","Package":"example.com/p","Test":"TestHang"}
+{"Action":"output","Package":"example.com/p","Test":"TestHang","Output":"panic: test timed out after 10m0s\n"}
+{"Action":"fail","Package":"example.com/p","Elapsed":600}
+`,
+			expected: []TestSuite{{
+				Name:     "example.com/p",
+				Duration: 600 * time.Second,
+				Cases: []TestCase{
+					{Suite: "example.com/p", Name: "TestHang", Status: TestErrored, Message: "test did not finish", Output: "panic: test timed out after 10m0s"},
+				},
+			}},
+		},
+		{
+			title: "package failure and non-json lines",
+			body: `# example.com/q
+q_test.go:3:2: undefined: missing
+{"Action":"output","Package":"example.com/q","Output":"FAIL\texample.com/q [build failed]\n"}
+{"Action":"fail","Package":"example.com/q","Elapsed":0}
+{"Action":"output","Package":"example.com/r","Output":"?   \texample.com/r\t[no test files]\n"}
+{"Action":"skip","Package":"example.com/r","Elapsed":0}
+`,
+			expected: []TestSuite{
+				{
+					Name: "example.com/q",
+					Cases: []TestCase{
+						{Suite: "example.com/q", Name: "(package)", Status: TestErrored, Message: "package failed"},
+					},
+				},
+				{
+					Name: "example.com/r",
+				},
+			},
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			actual, err := ReadGoTest(strings.NewReader(test.body))
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestGoTestTemplate(t *testing.T) {
+	tests := []struct {
+		title    string
+		suites   []TestSuite
+		expected string
+	}{
+		{
+			title: "failing",
+			suites: []TestSuite{
+				{
+					Name:     "example.com/p",
+					Duration: 1500 * time.Millisecond,
+					Cases: []TestCase{
+						{Suite: "example.com/p", Name: "TestA", Status: TestPassed},
+						{Suite: "example.com/p", Name: "TestB", Status: TestFailed, Message: "oops", Output: "    p_test.go:4: oops"},
+					},
+				},
+				{
+					Name:     "example.com/q",
+					Duration: 2 * time.Second,
+					Cases: []TestCase{
+						{Suite: "example.com/q", Name: "TestC", Status: TestSkipped},
+						{Suite: "example.com/q", Name: "(package)", Status: TestErrored, Message: "package failed"},
+					},
+				},
+			},
+			expected: "### ❌ 2 of 4 Go tests failed\n\n" +
+				"| Package | Passed | Failed | Skipped | Time |\n" +
+				"|---------|-------:|-------:|--------:|-----:|\n" +
+				"| ❌ `example.com/p` | 1 | 1 | 0 | 1.5s |\n" +
+				"| ❌ `example.com/q` | 0 | 1 | 1 | 2s |\n\n" +
+				"<details>\n<summary>example.com/p TestB</summary>\n\n```\n    p_test.go:4: oops\n```\n</details>\n\n" +
+				"<details>\n<summary>example.com/q (package)</summary>\n\n```\npackage failed\n```\n</details>",
+		},
+		{
+			title: "single passing",
+			suites: []TestSuite{{
+				Name:     "example.com/p",
+				Duration: time.Second,
+				Cases: []TestCase{
+					{Suite: "example.com/p", Name: "TestA", Status: TestPassed},
+				},
+			}},
+			expected: "### ✅ 1 Go test passed\n\n" +
+				"| Package | Passed | Failed | Skipped | Time |\n" +
+				"|---------|-------:|-------:|--------:|-----:|\n" +
+				"| ✅ `example.com/p` | 1 | 0 | 0 | 1s |",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			actual := renderBuiltin(t, "gotest", func(ctx *Context) {
+				ctx.Tests = NewTestReport(test.suites)
+			})
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
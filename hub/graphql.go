@@ -0,0 +1,159 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// defaultGraphQLURL is the GraphQL API endpoint of the public GitHub
+	// server.
+	defaultGraphQLURL = "https://api.github.com/graphql"
+
+	// nodesPerQuery is the largest number of nodes that can be looked up in a
+	// single query.
+	nodesPerQuery = 100
+
+	// minimizeCommentMutation collapses a comment, hiding it behind the given
+	// reason, like "OUTDATED".
+	//
+	// See https://docs.github.com/en/graphql/reference/mutations#minimizecomment
+	minimizeCommentMutation = `mutation($id: ID!, $classifier: ReportedContentClassifiers!) {
+  minimizeComment(input: {subjectId: $id, classifier: $classifier}) {
+    minimizedComment { isMinimized }
+  }
+}`
+
+	// minimizedCommentsQuery looks up which of the given comments have
+	// already been minimized.
+	minimizedCommentsQuery = `query($ids: [ID!]!) {
+  nodes(ids: $ids) {
+    ... on IssueComment { id isMinimized }
+  }
+}`
+)
+
+// GraphQLClient sends queries to the GitHub GraphQL API, for the few actions
+// that are not available from the REST API.
+type GraphQLClient struct {
+	// URL is the GraphQL API endpoint.
+	URL string
+
+	// client is an HTTP client that authenticates every request.
+	client *http.Client
+}
+
+// graphQLURL returns the GraphQL endpoint that corresponds with the given REST
+// API endpoint.
+func graphQLURL(apiURL string) string {
+	switch {
+	case apiURL == "" || apiURL == defaultAPIURL:
+		return defaultGraphQLURL
+	case strings.HasSuffix(apiURL, "/api/v3/"):
+		return strings.TrimSuffix(apiURL, "/v3/") + "/graphql"
+	default:
+		return strings.TrimSuffix(apiURL, "/") + "/graphql"
+	}
+}
+
+// graphQLError represents a single error returned by the GraphQL API.
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+// Do sends the given query, along with its variables, and decodes the data in
+// the response into result.
+func (c *GraphQLClient) Do(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("POST %s: %s", c.URL, resp.Status)
+	}
+
+	var payload struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return err
+	}
+
+	if len(payload.Errors) > 0 {
+		messages := make([]string, len(payload.Errors))
+		for index, e := range payload.Errors {
+			messages[index] = e.Message
+		}
+		return fmt.Errorf("POST %s: %s", c.URL, strings.Join(messages, "; "))
+	}
+
+	if result == nil || len(payload.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(payload.Data, result)
+}
+
+// MinimizeComment collapses the comment with the given node ID, marking it as
+// outdated.
+func MinimizeComment(ctx context.Context, client *GraphQLClient, nodeID string) error {
+	return client.Do(ctx, minimizeCommentMutation, map[string]interface{}{
+		"id":         nodeID,
+		"classifier": "OUTDATED",
+	}, nil)
+}
+
+// MinimizedComments returns the set of the comments with the given node IDs
+// that have already been minimized.
+func MinimizedComments(ctx context.Context, client *GraphQLClient, nodeIDs []string) (map[string]bool, error) {
+	minimized := map[string]bool{}
+
+	// The API limits the number of nodes that can be looked up at once.
+	for start := 0; start < len(nodeIDs); start += nodesPerQuery {
+		end := start + nodesPerQuery
+		if end > len(nodeIDs) {
+			end = len(nodeIDs)
+		}
+
+		var result struct {
+			Nodes []struct {
+				ID          string `json:"id"`
+				IsMinimized bool   `json:"isMinimized"`
+			} `json:"nodes"`
+		}
+		if err := client.Do(ctx, minimizedCommentsQuery, map[string]interface{}{"ids": nodeIDs[start:end]}, &result); err != nil {
+			return nil, err
+		}
+
+		for _, node := range result.Nodes {
+			if node.IsMinimized {
+				minimized[node.ID] = true
+			}
+		}
+	}
+	return minimized, nil
+}
@@ -0,0 +1,122 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeGraphQL is a fake GitHub GraphQL API that records every request, and
+// that responds with the given function.
+type fakeGraphQL struct {
+	// requests is the list of every request body received, in order.
+	requests []map[string]interface{}
+
+	// respond returns the body of the response to the given request.
+	respond func(request map[string]interface{}) string
+}
+
+func (f *fakeGraphQL) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&request)
+	f.requests = append(f.requests, request)
+	fmt.Fprint(w, f.respond(request))
+}
+
+// newTestGraphQLClient builds a GraphQL client that sends all requests to the
+// given handler, instead of to api.github.com. The returned server must be
+// closed by the caller.
+func newTestGraphQLClient(handler http.Handler) (*GraphQLClient, *httptest.Server) {
+	server := httptest.NewServer(handler)
+
+	return &GraphQLClient{URL: server.URL, client: server.Client()}, server
+}
+
+func TestMinimizeComment(t *testing.T) {
+	tests := []struct {
+		title    string
+		response string
+		error    string
+	}{
+		{
+			title:    "minimized",
+			response: `{"data": {"minimizeComment": {"minimizedComment": {"isMinimized": true}}}}`,
+		},
+		{
+			title:    "errors",
+			response: `{"errors": [{"message": "Could not resolve to a node"}, {"message": "Forbidden"}]}`,
+			error:    "Could not resolve to a node; Forbidden",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			fake := &fakeGraphQL{respond: func(map[string]interface{}) string {
+				return test.response
+			}}
+			client, server := newTestGraphQLClient(fake)
+			defer server.Close()
+
+			err := MinimizeComment(context.Background(), client, "IC_kwDOABCD")
+			if test.error != "" {
+				assert.EqualError(t, err, fmt.Sprintf("POST %s: %s", client.URL, test.error))
+			} else {
+				assert.Nil(t, err)
+			}
+
+			if assert.Len(t, fake.requests, 1) {
+				assert.Contains(t, fake.requests[0]["query"], "minimizeComment")
+				assert.Equal(t, map[string]interface{}{"id": "IC_kwDOABCD", "classifier": "OUTDATED"}, fake.requests[0]["variables"])
+			}
+		})
+	}
+}
+
+func TestMinimizedComments(t *testing.T) {
+	fake := &fakeGraphQL{respond: func(request map[string]interface{}) string {
+		var nodes []string
+		for _, id := range request["variables"].(map[string]interface{})["ids"].([]interface{}) {
+			minimized := strings.HasSuffix(id.(string), "0")
+			nodes = append(nodes, fmt.Sprintf(`{"id": %q, "isMinimized": %t}`, id, minimized))
+		}
+		return `{"data": {"nodes": [` + strings.Join(nodes, ",") + `]}}`
+	}}
+
+	var ids []string
+	for index := 1; index <= nodesPerQuery+25; index++ {
+		ids = append(ids, fmt.Sprintf("IC_%d", index))
+	}
+
+	client, server := newTestGraphQLClient(fake)
+	defer server.Close()
+
+	minimized, err := MinimizedComments(context.Background(), client, ids)
+	assert.Nil(t, err)
+	assert.Len(t, minimized, 12)
+	assert.True(t, minimized["IC_120"])
+	assert.False(t, minimized["IC_121"])
+
+	// Nodes are looked up in batches.
+	assert.Len(t, fake.requests, 2)
+}
+
+func TestGraphQLStatus(t *testing.T) {
+	client, server := newTestGraphQLClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := client.Do(context.Background(), "query { viewer { login } }", nil, nil)
+	assert.EqualError(t, err, fmt.Sprintf("POST %s: 401 Unauthorized", client.URL))
+}
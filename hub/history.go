@@ -0,0 +1,234 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxCommentLength is the largest number of characters that GitHub allows
+	// in the body of a single comment.
+	MaxCommentLength = 65536
+
+	// sectionPrefix and sectionSuffix surround the hidden timestamp that
+	// starts every section of a comment built up over many renders.
+	sectionPrefix = "<!-- hub-comment:section "
+	sectionSuffix = " -->"
+
+	// historyMarker is a hidden line that separates the latest render from the
+	// folded history of earlier renders.
+	historyMarker = "<!-- hub-comment:history -->"
+
+	// truncatedNotice is appended to any comment that had to be cut short.
+	truncatedNotice = "\n\n*This comment was truncated because it was too long.*"
+
+	// detailsOpen and detailsClose are the tags around a collapsible block,
+	// which must be balanced for the rest of the page to render correctly.
+	detailsOpen  = "<details"
+	detailsClose = "</details>"
+)
+
+// AppendSection adds the content of the given comment, as a new timestamped
+// section, to the bottom of the existing comment, or to the top if prepend is
+// true. The oldest sections are dropped until the result fits within
+// MaxCommentLength.
+func AppendSection(existing string, comment string, prepend bool) string {
+	meta, content, _ := ParseComment(comment)
+	latest := newSection(meta.Time, content)
+
+	sections := existingSections(existing, prepend)
+	if prepend {
+		sections = append([]string{latest}, sections...)
+	} else {
+		sections = append(sections, latest)
+	}
+
+	for {
+		body := meta.String() + "\n\n" + strings.Join(sections, "\n\n")
+		if len(sections) == 1 || utf8.RuneCountInString(body) <= MaxCommentLength {
+			return Truncate(body)
+		}
+
+		if prepend {
+			sections = sections[:len(sections)-1]
+		} else {
+			sections = sections[1:]
+		}
+	}
+}
+
+// AddHistory replaces the existing comment with the content of the given
+// comment, and folds every earlier render into a <details> block underneath,
+// newest first. The oldest renders are dropped until the result fits within
+// MaxCommentLength.
+func AddHistory(existing string, comment string) string {
+	meta, content, _ := ParseComment(comment)
+
+	var history []string
+	if existing != "" {
+		previous, body, _ := ParseComment(existing)
+		latest, older := splitHistory(body)
+
+		// A comment that was built up from sections, like one that was posted
+		// in the append or prepend modes, holds many earlier renders.
+		history = append(history, newestFirst(wrapSections(previous.Time, latest))...)
+		history = append(history, older...)
+	}
+
+	for {
+		body := meta.String() + "\n\n" + content
+		if len(history) > 0 {
+			body += fmt.Sprintf("\n\n%s\n<details><summary>Previous results (%d)</summary>\n\n%s\n\n</details>",
+				historyMarker, len(history), strings.Join(history, "\n\n"))
+		}
+
+		if len(history) == 0 || utf8.RuneCountInString(body) <= MaxCommentLength {
+			return Truncate(body)
+		}
+		history = history[:len(history)-1]
+	}
+}
+
+// Truncate cuts the given comment short, with a notice, if it is longer than
+// MaxCommentLength. Any <details> block left open by the cut is closed.
+func Truncate(comment string) string {
+	if utf8.RuneCountInString(comment) <= MaxCommentLength {
+		return comment
+	}
+
+	var (
+		runes = []rune(comment)
+		keep  = MaxCommentLength - utf8.RuneCountInString(truncatedNotice)
+	)
+	for {
+		kept := string(runes[:keep])
+
+		var closers string
+		if open := strings.Count(kept, detailsOpen) - strings.Count(kept, detailsClose); open > 0 {
+			closers = strings.Repeat("\n\n"+detailsClose, open)
+		}
+
+		// Make room for the closing tags, which may in turn change how many
+		// blocks are left open.
+		if extra := utf8.RuneCountInString(closers + truncatedNotice); keep+extra > MaxCommentLength {
+			keep = MaxCommentLength - extra
+			continue
+		}
+		return kept + closers + truncatedNotice
+	}
+}
+
+// newSection returns the given content as a section, starting with a hidden
+// marker and a visible timestamp.
+func newSection(timestamp time.Time, content string) string {
+	when := "Earlier"
+	if !timestamp.IsZero() {
+		when = timestamp.UTC().Format("2006-01-02 15:04:05 UTC")
+	}
+	return fmt.Sprintf("%s%s%s\n**%s**\n\n%s", sectionPrefix, timestamp.UTC().Format(time.RFC3339), sectionSuffix, when, content)
+}
+
+// existingSections splits the content of the given comment into its sections.
+// A comment that was not built up from sections, like one that was posted in
+// another mode, is treated as a single section. The earlier renders of a
+// comment that was posted in the history mode are unwrapped into sections of
+// their own, ordered oldest first, or newest first if prepend is true.
+func existingSections(existing string, prepend bool) []string {
+	meta, content, _ := ParseComment(existing)
+	latest, older := splitHistory(content)
+
+	sections := append(wrapSections(meta.Time, latest), older...)
+	if !prepend && len(older) > 0 {
+		for i, j := 0, len(sections)-1; i < j; i, j = i+1, j-1 {
+			sections[i], sections[j] = sections[j], sections[i]
+		}
+	}
+	return sections
+}
+
+// wrapSections splits the given content into its sections. Any leading content
+// that is not already inside a section is wrapped into one, with the given
+// timestamp.
+func wrapSections(timestamp time.Time, content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	sections := splitSections(content)
+	if !strings.HasPrefix(content, sectionPrefix) {
+		sections[0] = newSection(timestamp, sections[0])
+	}
+	return sections
+}
+
+// sectionTime returns the timestamp from the hidden marker that starts the
+// given section, or the zero time if it has none.
+func sectionTime(section string) time.Time {
+	line := strings.SplitN(section, "\n", 2)[0]
+	if !strings.HasPrefix(line, sectionPrefix) || !strings.HasSuffix(line, sectionSuffix) {
+		return time.Time{}
+	}
+
+	timestamp, _ := time.Parse(time.RFC3339, strings.TrimSuffix(strings.TrimPrefix(line, sectionPrefix), sectionSuffix))
+	return timestamp
+}
+
+// newestFirst sorts the given sections by their timestamps, newest first.
+// Sections with the same timestamp keep their order.
+func newestFirst(sections []string) []string {
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sectionTime(sections[i]).After(sectionTime(sections[j]))
+	})
+	return sections
+}
+
+// splitSections splits the given content at the start of every section. Any
+// content before the first section is kept as-is.
+func splitSections(content string) []string {
+	var (
+		sections []string
+		current  []string
+	)
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, sectionPrefix) && strings.HasSuffix(line, sectionSuffix) && len(current) > 0 {
+			sections = append(sections, strings.TrimSpace(strings.Join(current, "\n")))
+			current = nil
+		}
+		current = append(current, line)
+	}
+
+	if section := strings.TrimSpace(strings.Join(current, "\n")); section != "" {
+		sections = append(sections, section)
+	}
+	return sections
+}
+
+// splitHistory splits the given content into the latest render, and the
+// sections of every earlier render.
+func splitHistory(content string) (string, []string) {
+	index := strings.Index(content, "\n"+historyMarker+"\n")
+	if index < 0 {
+		return strings.TrimSpace(content), nil
+	}
+
+	latest := strings.TrimSpace(content[:index])
+	folded := content[index+len(historyMarker)+2:]
+
+	// Unwrap the <details> block around the earlier renders.
+	if start := strings.Index(folded, "</summary>"); start >= 0 {
+		folded = folded[start+len("</summary>"):]
+	}
+	folded = strings.TrimSuffix(strings.TrimSpace(folded), "</details>")
+	folded = strings.TrimSpace(folded)
+	if folded == "" {
+		return latest, nil
+	}
+	return latest, splitSections(folded)
+}
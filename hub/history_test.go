@@ -0,0 +1,269 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// render returns a comment with the given content, as if it was rendered at
+// the given hour.
+func render(hour int, content string) string {
+	meta := Metadata{
+		Version: metaVersion,
+		Type:    "default",
+		Hash:    HashContent(content),
+		Time:    time.Date(2018, 9, 14, hour, 0, 0, 0, time.UTC),
+	}
+	return meta.String() + "\n\n" + content
+}
+
+func TestAppendSection(t *testing.T) {
+	tests := []struct {
+		title    string
+		existing string
+		prepend  bool
+		expected string
+	}{
+		{
+			title: "no existing comment",
+			expected: "<!-- hub-comment:section 2018-09-14T03:00:00Z -->\n**2018-09-14 03:00:00 UTC**\n\n" +
+				"Third",
+		},
+		{
+			title:    "append to existing comment",
+			existing: render(1, "First"),
+			expected: "<!-- hub-comment:section 2018-09-14T01:00:00Z -->\n**2018-09-14 01:00:00 UTC**\n\n" +
+				"First\n\n" +
+				"<!-- hub-comment:section 2018-09-14T03:00:00Z -->\n**2018-09-14 03:00:00 UTC**\n\n" +
+				"Third",
+		},
+		{
+			title:    "append to existing sections",
+			existing: AppendSection(render(1, "First"), render(2, "Second"), false),
+			expected: "<!-- hub-comment:section 2018-09-14T01:00:00Z -->\n**2018-09-14 01:00:00 UTC**\n\n" +
+				"First\n\n" +
+				"<!-- hub-comment:section 2018-09-14T02:00:00Z -->\n**2018-09-14 02:00:00 UTC**\n\n" +
+				"Second\n\n" +
+				"<!-- hub-comment:section 2018-09-14T03:00:00Z -->\n**2018-09-14 03:00:00 UTC**\n\n" +
+				"Third",
+		},
+		{
+			title:    "prepend to existing sections",
+			existing: AppendSection(render(1, "First"), render(2, "Second"), true),
+			prepend:  true,
+			expected: "<!-- hub-comment:section 2018-09-14T03:00:00Z -->\n**2018-09-14 03:00:00 UTC**\n\n" +
+				"Third\n\n" +
+				"<!-- hub-comment:section 2018-09-14T02:00:00Z -->\n**2018-09-14 02:00:00 UTC**\n\n" +
+				"Second\n\n" +
+				"<!-- hub-comment:section 2018-09-14T01:00:00Z -->\n**2018-09-14 01:00:00 UTC**\n\n" +
+				"First",
+		},
+		{
+			title:    "legacy comment",
+			existing: "[//]: # (meta:type=default)\n\nFirst",
+			expected: "<!-- hub-comment:section 0001-01-01T00:00:00Z -->\n**Earlier**\n\n" +
+				"First\n\n" +
+				"<!-- hub-comment:section 2018-09-14T03:00:00Z -->\n**2018-09-14 03:00:00 UTC**\n\n" +
+				"Third",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			meta, content, found := ParseComment(AppendSection(test.existing, render(3, "Third"), test.prepend))
+			assert.True(t, found)
+			assert.Equal(t, test.expected, content)
+
+			// The metadata always describes the latest render.
+			assert.Equal(t, HashContent("Third"), meta.Hash)
+		})
+	}
+}
+
+func TestAppendSectionHistory(t *testing.T) {
+	tests := []struct {
+		title    string
+		prepend  bool
+		expected string
+	}{
+		{
+			title: "switch to append",
+			expected: "<!-- hub-comment:section 2018-09-14T01:00:00Z -->\n**2018-09-14 01:00:00 UTC**\n\n" +
+				"First\n\n" +
+				"<!-- hub-comment:section 2018-09-14T02:00:00Z -->\n**2018-09-14 02:00:00 UTC**\n\n" +
+				"Second\n\n" +
+				"<!-- hub-comment:section 2018-09-14T03:00:00Z -->\n**2018-09-14 03:00:00 UTC**\n\n" +
+				"Third",
+		},
+		{
+			title:   "switch to prepend",
+			prepend: true,
+			expected: "<!-- hub-comment:section 2018-09-14T03:00:00Z -->\n**2018-09-14 03:00:00 UTC**\n\n" +
+				"Third\n\n" +
+				"<!-- hub-comment:section 2018-09-14T02:00:00Z -->\n**2018-09-14 02:00:00 UTC**\n\n" +
+				"Second\n\n" +
+				"<!-- hub-comment:section 2018-09-14T01:00:00Z -->\n**2018-09-14 01:00:00 UTC**\n\n" +
+				"First",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			existing := AddHistory(AddHistory("", render(1, "First")), render(2, "Second"))
+
+			// The folded history is unwrapped, so that every earlier render
+			// becomes a separate section.
+			_, content, _ := ParseComment(AppendSection(existing, render(3, "Third"), test.prepend))
+			assert.Equal(t, test.expected, content)
+			assert.NotContains(t, content, historyMarker)
+		})
+	}
+}
+
+func TestAddHistory(t *testing.T) {
+	first := AddHistory("", render(1, "First"))
+	assert.Equal(t, render(1, "First"), first)
+
+	second := AddHistory(first, render(2, "Second"))
+	third := AddHistory(second, render(3, "Third"))
+
+	meta, content, found := ParseComment(third)
+	assert.True(t, found)
+	assert.Equal(t, HashContent("Third"), meta.Hash)
+	assert.Equal(t, "Third\n\n"+
+		"<!-- hub-comment:history -->\n"+
+		"<details><summary>Previous results (2)</summary>\n\n"+
+		"<!-- hub-comment:section 2018-09-14T02:00:00Z -->\n**2018-09-14 02:00:00 UTC**\n\n"+
+		"Second\n\n"+
+		"<!-- hub-comment:section 2018-09-14T01:00:00Z -->\n**2018-09-14 01:00:00 UTC**\n\n"+
+		"First\n\n"+
+		"</details>", content)
+}
+
+func TestAddHistorySections(t *testing.T) {
+	tests := []struct {
+		title   string
+		prepend bool
+	}{
+		{
+			title: "switch from append",
+		},
+		{
+			title:   "switch from prepend",
+			prepend: true,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			existing := AppendSection(AppendSection("", render(1, "First"), test.prepend), render(2, "Second"), test.prepend)
+
+			// Every existing section becomes a separate earlier render.
+			_, content, _ := ParseComment(AddHistory(existing, render(3, "Third")))
+			assert.Equal(t, "Third\n\n"+
+				"<!-- hub-comment:history -->\n"+
+				"<details><summary>Previous results (2)</summary>\n\n"+
+				"<!-- hub-comment:section 2018-09-14T02:00:00Z -->\n**2018-09-14 02:00:00 UTC**\n\n"+
+				"Second\n\n"+
+				"<!-- hub-comment:section 2018-09-14T01:00:00Z -->\n**2018-09-14 01:00:00 UTC**\n\n"+
+				"First\n\n"+
+				"</details>", content)
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		title    string
+		comment  string
+		expected string
+	}{
+		{
+			title:    "short comment",
+			comment:  "<details><summary>Tests</summary>\n\nHello\n\n</details>",
+			expected: "<details><summary>Tests</summary>\n\nHello\n\n</details>",
+		},
+		{
+			title:    "long comment",
+			comment:  strings.Repeat("x", MaxCommentLength+1),
+			expected: truncatedNotice,
+		},
+		{
+			title:    "open details block",
+			comment:  "<details><summary>Tests</summary>\n\n" + strings.Repeat("x", MaxCommentLength),
+			expected: "\n\n</details>" + truncatedNotice,
+		},
+		{
+			title:    "nested details blocks",
+			comment:  "<details>\n\n<details>\n\n" + strings.Repeat("x", MaxCommentLength),
+			expected: "\n\n</details>\n\n</details>" + truncatedNotice,
+		},
+		{
+			title:    "closed details block",
+			comment:  "<details>\n\nHello\n\n</details>\n\n" + strings.Repeat("x", MaxCommentLength),
+			expected: "x" + truncatedNotice,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			actual := Truncate(test.comment)
+			assert.True(t, utf8.RuneCountInString(actual) <= MaxCommentLength)
+			assert.True(t, strings.HasSuffix(actual, test.expected))
+			assert.Equal(t, strings.Count(actual, "<details>"), strings.Count(actual, "</details>"))
+		})
+	}
+}
+
+func TestCommentLength(t *testing.T) {
+	var (
+		// Each render is a little over a third of the limit, using multibyte
+		// characters to check that characters are counted, not bytes.
+		large  = strings.Repeat("✅", MaxCommentLength/3)
+		first  = render(1, "First"+large)
+		second = render(2, "Second"+large)
+		third  = render(3, "Third"+large)
+	)
+
+	t.Run("1 append drops oldest", func(t *testing.T) {
+		actual := AppendSection(AppendSection(AppendSection("", first, false), second, false), third, false)
+		assert.True(t, utf8.RuneCountInString(actual) <= MaxCommentLength)
+		assert.NotContains(t, actual, "First")
+		assert.Contains(t, actual, "Second")
+		assert.Contains(t, actual, "Third")
+	})
+
+	t.Run("2 prepend drops oldest", func(t *testing.T) {
+		actual := AppendSection(AppendSection(AppendSection("", first, true), second, true), third, true)
+		assert.True(t, utf8.RuneCountInString(actual) <= MaxCommentLength)
+		assert.NotContains(t, actual, "First")
+		assert.True(t, strings.Index(actual, "Third") < strings.Index(actual, "Second"))
+	})
+
+	t.Run("3 history drops oldest", func(t *testing.T) {
+		actual := AddHistory(AddHistory(AddHistory("", first), second), third)
+		assert.True(t, utf8.RuneCountInString(actual) <= MaxCommentLength)
+		assert.NotContains(t, actual, "First")
+		assert.Contains(t, actual, "Previous results (1)")
+	})
+
+	t.Run("4 single render is truncated", func(t *testing.T) {
+		actual := AddHistory(first, render(4, strings.Repeat("❌", MaxCommentLength)))
+		assert.Equal(t, MaxCommentLength, utf8.RuneCountInString(actual))
+		assert.True(t, strings.HasSuffix(actual, truncatedNotice))
+		assert.NotContains(t, actual, "First")
+	})
+}
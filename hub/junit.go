@@ -0,0 +1,157 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// junitSuites represents the root element of a JUnit XML report, which may be
+// either a <testsuites> or a <testsuite> element.
+type junitSuites struct {
+	XMLName xml.Name
+	junitSuite
+}
+
+// junitSuite represents a single <testsuite> element. Some tools nest test
+// suites inside of each other.
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Time   string       `xml:"time,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+// junitCase represents a single <testcase> element.
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure"`
+	Error     *junitFailure `xml:"error"`
+	Skipped   *junitFailure `xml:"skipped"`
+	SystemOut string        `xml:"system-out"`
+	SystemErr string        `xml:"system-err"`
+}
+
+// junitFailure represents a <failure>, <error>, or <skipped> element.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// ReadJUnit reads the named JUnit XML report, as written by tools like
+// go-junit-report, pytest, jest, and maven surefire, and returns every test
+// suite it contains.
+func ReadJUnit(filename string) ([]TestSuite, error) {
+	body, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var root junitSuites
+	if err := xml.Unmarshal(body, &root); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	switch root.XMLName.Local {
+	case "testsuites":
+		// Only the children of a <testsuites> element are real suites.
+		root.Cases = nil
+	case "testsuite":
+		// A lone <testsuite> is treated as a list containing only itself.
+		root.junitSuite = junitSuite{Suites: []junitSuite{root.junitSuite}}
+	default:
+		return nil, fmt.Errorf("%s: unknown JUnit root element <%s>", filename, root.XMLName.Local)
+	}
+
+	var suites []TestSuite
+	for _, suite := range root.Suites {
+		suites = append(suites, flattenJUnit(suite)...)
+	}
+	return suites, nil
+}
+
+// flattenJUnit converts the given suite, along with any suites nested inside
+// of it, into a flat list of test suites.
+func flattenJUnit(suite junitSuite) []TestSuite {
+	result := TestSuite{
+		Name:     suite.Name,
+		Duration: junitDuration(suite.Time),
+	}
+
+	sum := time.Duration(0)
+	for _, c := range suite.Cases {
+		tc := TestCase{
+			Suite:    suite.Name,
+			Class:    c.ClassName,
+			Name:     c.Name,
+			Status:   TestPassed,
+			Duration: junitDuration(c.Time),
+		}
+
+		var detail *junitFailure
+		switch {
+		case c.Error != nil:
+			tc.Status, detail = TestErrored, c.Error
+		case c.Failure != nil:
+			tc.Status, detail = TestFailed, c.Failure
+		case c.Skipped != nil:
+			tc.Status, detail = TestSkipped, c.Skipped
+		}
+
+		if detail != nil {
+			tc.Output = strings.TrimSpace(detail.Body)
+			tc.Message = firstLine(first(detail.Message, tc.Output))
+		}
+		if tc.Failed() && tc.Output == "" {
+			tc.Output = strings.TrimSpace(strings.TrimSpace(c.SystemOut) + "\n" + strings.TrimSpace(c.SystemErr))
+		}
+
+		sum += tc.Duration
+		result.Cases = append(result.Cases, tc)
+	}
+
+	// Not every tool records the time taken by a suite.
+	if result.Duration == 0 {
+		result.Duration = sum
+	}
+
+	suites := []TestSuite{result}
+	if len(suite.Cases) == 0 && len(suite.Suites) > 0 {
+		// Suites that only group other suites are dropped.
+		suites = nil
+	}
+	for _, child := range suite.Suites {
+		suites = append(suites, flattenJUnit(child)...)
+	}
+	return suites
+}
+
+// junitDuration parses a time attribute, given in fractional seconds, like
+// "1.25". Some tools format large values with thousands separators, like
+// "1,234.5". Malformed or missing values are treated as zero.
+func junitDuration(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(strings.Replace(value, ",", "", -1), 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// firstLine returns the first non-blank line of the given string.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
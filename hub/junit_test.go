@@ -0,0 +1,205 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadJUnit(t *testing.T) {
+	tests := []struct {
+		title    string
+		body     string
+		expected []TestSuite
+		error    string
+	}{
+		{
+			title: "go-junit-report",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+	<testsuite tests="2" failures="1" time="0.020" name="github.com/joshdk/hub-comment/hub">
+		<testcase classname="hub" name="TestTrim" time="0.010"></testcase>
+		<testcase classname="hub" name="TestExecute" time="0.010">
+			<failure message="Failed" type="">context_test.go:42: expected "a", got "b"</failure>
+		</testcase>
+	</testsuite>
+</testsuites>`,
+			expected: []TestSuite{{
+				Name:     "github.com/joshdk/hub-comment/hub",
+				Duration: 20 * time.Millisecond,
+				Cases: []TestCase{
+					{Suite: "github.com/joshdk/hub-comment/hub", Class: "hub", Name: "TestTrim", Status: TestPassed, Duration: 10 * time.Millisecond},
+					{Suite: "github.com/joshdk/hub-comment/hub", Class: "hub", Name: "TestExecute", Status: TestFailed, Duration: 10 * time.Millisecond, Message: "Failed", Output: `context_test.go:42: expected "a", got "b"`},
+				},
+			}},
+		},
+		{
+			title: "surefire",
+			body: `<testsuite name="com.example.AppTest" time="1,234.5">
+	<testcase name="testSkipped" classname="com.example.AppTest"><skipped message="not ready"/></testcase>
+	<testcase name="testError" classname="com.example.AppTest" time="0.5">
+		<error message="boom" type="java.lang.NullPointerException">java.lang.NullPointerException: boom
+	at com.example.AppTest.testError(AppTest.java:12)</error>
+	</testcase>
+</testsuite>`,
+			expected: []TestSuite{{
+				Name:     "com.example.AppTest",
+				Duration: 1234500 * time.Millisecond,
+				Cases: []TestCase{
+					{Suite: "com.example.AppTest", Class: "com.example.AppTest", Name: "testSkipped", Status: TestSkipped, Message: "not ready"},
+					{Suite: "com.example.AppTest", Class: "com.example.AppTest", Name: "testError", Status: TestErrored, Duration: 500 * time.Millisecond, Message: "boom", Output: "java.lang.NullPointerException: boom\n\tat com.example.AppTest.testError(AppTest.java:12)"},
+				},
+			}},
+		},
+		{
+			title: "nested suites without suite times",
+			body: `<testsuites name="jest tests">
+	<testsuite name="app">
+		<testsuite name="app.test.js">
+			<testcase name="renders" time="0.25"/>
+			<testcase name="fails" time="0.5">
+				<failure>Error: expected true
+    at Object.&lt;anonymous&gt; (app.test.js:3:5)</failure>
+				<system-out>ignored</system-out>
+			</testcase>
+		</testsuite>
+	</testsuite>
+</testsuites>`,
+			expected: []TestSuite{{
+				Name:     "app.test.js",
+				Duration: 750 * time.Millisecond,
+				Cases: []TestCase{
+					{Suite: "app.test.js", Name: "renders", Status: TestPassed, Duration: 250 * time.Millisecond},
+					{Suite: "app.test.js", Name: "fails", Status: TestFailed, Duration: 500 * time.Millisecond, Message: "Error: expected true", Output: "Error: expected true\n    at Object.<anonymous> (app.test.js:3:5)"},
+				},
+			}},
+		},
+		{
+			title: "failure output from system-err",
+			body: `<testsuite name="pytest">
+	<testcase classname="tests.test_app" name="test_crash"><failure message="crashed"/><system-err>Traceback</system-err></testcase>
+</testsuite>`,
+			expected: []TestSuite{{
+				Name: "pytest",
+				Cases: []TestCase{
+					{Suite: "pytest", Class: "tests.test_app", Name: "test_crash", Status: TestFailed, Message: "crashed", Output: "Traceback"},
+				},
+			}},
+		},
+		{
+			title: "unknown root",
+			body:  `<coverage/>`,
+			error: "report.xml: unknown JUnit root element <coverage>",
+		},
+		{
+			title: "malformed",
+			body:  `<testsuite>`,
+			error: "report.xml: XML syntax error on line 1: unexpected EOF",
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "hub-comment")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "report.xml")
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			assert.Nil(t, ioutil.WriteFile(path, []byte(test.body), 0644))
+
+			actual, err := ReadJUnit(path)
+			if test.error != "" {
+				assert.EqualError(t, err, filepath.Join(dir, test.error))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestTestsTemplate(t *testing.T) {
+	tests := []struct {
+		title    string
+		suites   []TestSuite
+		expected string
+	}{
+		{
+			title: "passing",
+			suites: []TestSuite{{
+				Name:     "unit",
+				Duration: 1500 * time.Millisecond,
+				Cases: []TestCase{
+					{Suite: "unit", Name: "TestA", Status: TestPassed},
+					{Suite: "unit", Name: "TestB", Status: TestSkipped},
+				},
+			}},
+			expected: "### ✅ All 2 tests passed\n\n1 passed, 0 failed, and 1 skipped in 1.5s.",
+		},
+		{
+			title: "single passing",
+			suites: []TestSuite{{
+				Name:     "unit",
+				Duration: time.Second,
+				Cases: []TestCase{
+					{Suite: "unit", Name: "TestA", Status: TestPassed},
+				},
+			}},
+			expected: "### ✅ 1 test passed\n\n1 passed, 0 failed, and 0 skipped in 1s.",
+		},
+		{
+			title: "single failing",
+			suites: []TestSuite{{
+				Name:     "unit",
+				Duration: time.Second,
+				Cases: []TestCase{
+					{Suite: "unit", Name: "TestA", Status: TestFailed, Message: "oops"},
+				},
+			}},
+			expected: "### ❌ 1 of 1 test failed\n\n" +
+				"0 passed, 1 failed, and 0 skipped in 1s.\n\n" +
+				"| Suite | Test | Message |\n" +
+				"|-------|------|---------|\n" +
+				"| unit | TestA | oops |",
+		},
+		{
+			title: "failing",
+			suites: []TestSuite{{
+				Name:     "unit",
+				Duration: 2 * time.Second,
+				Cases: []TestCase{
+					{Suite: "unit", Name: "TestA", Status: TestPassed},
+					{Suite: "unit", Name: "TestB", Status: TestFailed, Message: "a | b", Output: "trace"},
+					{Suite: "unit", Name: "TestC", Status: TestErrored, Message: "boom"},
+				},
+			}},
+			expected: "### ❌ 2 of 3 tests failed\n\n" +
+				"1 passed, 2 failed, and 0 skipped in 2s.\n\n" +
+				"| Suite | Test | Message |\n" +
+				"|-------|------|---------|\n" +
+				"| unit | TestB | a \\| b |\n" +
+				"| unit | TestC | boom |\n\n" +
+				"<details>\n<summary>unit TestB</summary>\n\n```\ntrace\n```\n</details>",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			actual := renderBuiltin(t, "tests", func(ctx *Context) {
+				ctx.Tests = NewTestReport(test.suites)
+			})
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// LintError is the severity of a finding that must be fixed.
+	LintError = "error"
+
+	// LintWarning is the severity of a finding that should be fixed.
+	LintWarning = "warning"
+
+	// LintNote is the severity of a purely informational finding.
+	LintNote = "note"
+)
+
+// lintIcons maps every severity to an icon, for use when rendering findings.
+var lintIcons = map[string]string{
+	LintError:   "❌",
+	LintWarning: "⚠️",
+	LintNote:    "ℹ️",
+}
+
+// Lint represents the combined findings of one or more linters.
+type Lint struct {
+	// Findings is the list of every finding, sorted by file and line.
+	Findings []LintFinding
+
+	// Total is the number of findings.
+	Total int
+
+	// Errors is the number of findings with a severity of LintError.
+	Errors int
+
+	// Warnings is the number of findings with a severity of LintWarning.
+	Warnings int
+
+	// Notes is the number of findings with a severity of LintNote.
+	Notes int
+}
+
+// LintFinding represents a single problem reported by a linter.
+type LintFinding struct {
+	// Tool is the name of the linter, if known.
+	Tool string
+
+	// Rule is the name of the rule that was violated, if known.
+	Rule string
+
+	// Severity is one of LintError, LintWarning, or LintNote.
+	Severity string
+
+	// Message is a description of the problem.
+	Message string
+
+	// File is the path of the file containing the problem, relative to the
+	// repository root when possible.
+	File string
+
+	// Line is the line number of the start of the problem, or zero if not
+	// known.
+	Line int
+
+	// Column is the column number of the start of the problem, or zero if
+	// not known.
+	Column int
+}
+
+// LintGroup represents a list of findings that share a file, rule, or
+// severity.
+type LintGroup struct {
+	// Name is the file, rule, or severity shared by every finding.
+	Name string
+
+	// Findings is the list of findings in the group.
+	Findings []LintFinding
+}
+
+// NewLint combines the given findings into a single summary.
+func NewLint(findings []LintFinding) *Lint {
+	lint := &Lint{}
+	lint.Add(findings...)
+	return lint
+}
+
+// Add includes the given findings in the summary, and updates all counts.
+func (l *Lint) Add(findings ...LintFinding) {
+	for _, finding := range findings {
+		l.Findings = append(l.Findings, finding)
+		l.Total++
+		switch finding.Severity {
+		case LintError:
+			l.Errors++
+		case LintWarning:
+			l.Warnings++
+		default:
+			l.Notes++
+		}
+	}
+
+	sort.SliceStable(l.Findings, func(i, j int) bool {
+		a, b := l.Findings[i], l.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+}
+
+// group splits every finding into groups, keyed by the given function, in
+// order of first appearance.
+func (l *Lint) group(key func(LintFinding) string) []LintGroup {
+	var (
+		groups []LintGroup
+		index  = map[string]int{}
+	)
+	for _, finding := range l.Findings {
+		name := key(finding)
+		if _, found := index[name]; !found {
+			index[name] = len(groups)
+			groups = append(groups, LintGroup{Name: name})
+		}
+		groups[index[name]].Findings = append(groups[index[name]].Findings, finding)
+	}
+	return groups
+}
+
+// ByFile returns every finding grouped by file, sorted by file.
+func (l *Lint) ByFile() []LintGroup {
+	return l.group(func(f LintFinding) string { return f.File })
+}
+
+// ByRule returns every finding grouped by rule, sorted by the number of
+// findings, most first.
+func (l *Lint) ByRule() []LintGroup {
+	groups := l.group(func(f LintFinding) string { return f.Rule })
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i].Findings) > len(groups[j].Findings)
+	})
+	return groups
+}
+
+// BySeverity returns every finding grouped by severity, sorted from errors to
+// notes.
+func (l *Lint) BySeverity() []LintGroup {
+	rank := map[string]int{LintError: 0, LintWarning: 1, LintNote: 2}
+	groups := l.group(func(f LintFinding) string { return f.Severity })
+	sort.SliceStable(groups, func(i, j int) bool {
+		return rank[groups[i].Name] < rank[groups[j].Name]
+	})
+	return groups
+}
+
+// ReadLint reads the named SARIF or checkstyle XML report, as written by
+// linters like golangci-lint, eslint, and semgrep, and returns every finding
+// it contains. The format is detected from the file contents. File paths are
+// made relative to root when possible.
+func ReadLint(filename string, root string) ([]LintFinding, error) {
+	body, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []LintFinding
+	switch trimmed := bytes.TrimSpace(body); {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		findings, err = parseSARIF(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		findings, err = parseCheckstyle(trimmed)
+	default:
+		return nil, fmt.Errorf("%s: unknown lint report format", filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	for index := range findings {
+		findings[index].File = relativePath(findings[index].File, root)
+	}
+	return findings, nil
+}
+
+// relativePath converts the given path or file URI into a slash separated
+// path, relative to root if it is inside of root.
+func relativePath(path string, root string) string {
+	if strings.HasPrefix(path, "file://") {
+		if parsed, err := url.Parse(path); err == nil {
+			path = parsed.Path
+		}
+	}
+
+	if root != "" && filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// sarifLog represents the subset of a SARIF log that is needed to list every
+// finding.
+//
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Runs []struct {
+		Tool struct {
+			Driver struct {
+				Name  string `json:"name"`
+				Rules []struct {
+					ID                   string `json:"id"`
+					DefaultConfiguration struct {
+						Level string `json:"level"`
+					} `json:"defaultConfiguration"`
+				} `json:"rules"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []struct {
+			RuleID    string `json:"ruleId"`
+			RuleIndex *int   `json:"ruleIndex"`
+			Level     string `json:"level"`
+			Message   struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine   int `json:"startLine"`
+						StartColumn int `json:"startColumn"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+// parseSARIF returns every finding in the given SARIF log.
+func parseSARIF(body []byte) ([]LintFinding, error) {
+	var log sarifLog
+	if err := json.Unmarshal(body, &log); err != nil {
+		return nil, err
+	}
+
+	var findings []LintFinding
+	for _, run := range log.Runs {
+		rules := run.Tool.Driver.Rules
+		for _, result := range run.Results {
+			finding := LintFinding{
+				Tool:     run.Tool.Driver.Name,
+				Rule:     result.RuleID,
+				Severity: result.Level,
+				Message:  result.Message.Text,
+			}
+
+			// Results may refer to their rule by index, rather than by
+			// name, and inherit its default level.
+			if index := result.RuleIndex; index != nil && *index >= 0 && *index < len(rules) {
+				finding.Rule = first(finding.Rule, rules[*index].ID)
+				finding.Severity = first(finding.Severity, rules[*index].DefaultConfiguration.Level)
+			}
+			for _, rule := range rules {
+				if rule.ID == finding.Rule {
+					finding.Severity = first(finding.Severity, rule.DefaultConfiguration.Level)
+				}
+			}
+
+			switch finding.Severity {
+			case LintError, LintNote:
+			case "none":
+				finding.Severity = LintNote
+			default:
+				finding.Severity = LintWarning
+			}
+
+			if len(result.Locations) > 0 {
+				location := result.Locations[0].PhysicalLocation
+				finding.File, _ = url.PathUnescape(location.ArtifactLocation.URI)
+				finding.Line = location.Region.StartLine
+				finding.Column = location.Region.StartColumn
+			}
+
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// checkstyleReport represents a checkstyle XML report.
+type checkstyleReport struct {
+	XMLName xml.Name `xml:"checkstyle"`
+	Files   []struct {
+		Name   string `xml:"name,attr"`
+		Errors []struct {
+			Line     int    `xml:"line,attr"`
+			Column   int    `xml:"column,attr"`
+			Severity string `xml:"severity,attr"`
+			Message  string `xml:"message,attr"`
+			Source   string `xml:"source,attr"`
+		} `xml:"error"`
+	} `xml:"file"`
+}
+
+// parseCheckstyle returns every finding in the given checkstyle XML report.
+func parseCheckstyle(body []byte) ([]LintFinding, error) {
+	var report checkstyleReport
+	if err := xml.Unmarshal(body, &report); err != nil {
+		return nil, err
+	}
+
+	var findings []LintFinding
+	for _, file := range report.Files {
+		for _, e := range file.Errors {
+			finding := LintFinding{
+				Rule:     e.Source,
+				Severity: LintWarning,
+				Message:  e.Message,
+				File:     file.Name,
+				Line:     e.Line,
+				Column:   e.Column,
+			}
+
+			switch e.Severity {
+			case "error":
+				finding.Severity = LintError
+			case "info", "ignore":
+				finding.Severity = LintNote
+			}
+
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,194 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadLint(t *testing.T) {
+	tests := []struct {
+		title    string
+		body     string
+		expected []LintFinding
+		error    string
+	}{
+		{
+			title: "empty sarif",
+			body:  `{"version": "2.1.0", "runs": []}`,
+		},
+		{
+			title: "sarif",
+			body: `{
+  "version": "2.1.0",
+  "runs": [{
+    "tool": {"driver": {"name": "semgrep", "rules": [
+      {"id": "no-exec", "defaultConfiguration": {"level": "error"}},
+      {"id": "style"}
+    ]}},
+    "results": [
+      {
+        "ruleId": "no-exec",
+        "message": {"text": "avoid exec"},
+        "locations": [{"physicalLocation": {
+          "artifactLocation": {"uri": "file://ROOT/cmd/main.go"},
+          "region": {"startLine": 12, "startColumn": 3}
+        }}]
+      },
+      {
+        "ruleIndex": 1,
+        "level": "note",
+        "message": {"text": "prefer\nshorter names"},
+        "locations": [{"physicalLocation": {
+          "artifactLocation": {"uri": "hub/my%20file.go"},
+          "region": {"startLine": 4}
+        }}]
+      },
+      {
+        "ruleId": "unknown",
+        "message": {"text": "no location"}
+      }
+    ]
+  }]
+}`,
+			expected: []LintFinding{
+				{Tool: "semgrep", Rule: "no-exec", Severity: LintError, Message: "avoid exec", File: "cmd/main.go", Line: 12, Column: 3},
+				{Tool: "semgrep", Rule: "style", Severity: LintNote, Message: "prefer\nshorter names", File: "hub/my file.go", Line: 4},
+				{Tool: "semgrep", Rule: "unknown", Severity: LintWarning, Message: "no location"},
+			},
+		},
+		{
+			title: "checkstyle",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="5.0">
+  <file name="ROOT/hub/context.go">
+    <error line="10" column="2" severity="error" message="Error return value is not checked" source="errcheck"/>
+    <error line="20" column="1" severity="info" message="comment should be of the form" source="golint"/>
+  </file>
+  <file name="/elsewhere/main.js">
+    <error line="3" severity="warning" message="Unexpected console statement." source="eslint.rules.no-console"/>
+  </file>
+</checkstyle>`,
+			expected: []LintFinding{
+				{Rule: "errcheck", Severity: LintError, Message: "Error return value is not checked", File: "hub/context.go", Line: 10, Column: 2},
+				{Rule: "golint", Severity: LintNote, Message: "comment should be of the form", File: "hub/context.go", Line: 20, Column: 1},
+				{Rule: "eslint.rules.no-console", Severity: LintWarning, Message: "Unexpected console statement.", File: "/elsewhere/main.js", Line: 3},
+			},
+		},
+		{
+			title: "unknown format",
+			body:  "hub/context.go:10:2: oops",
+			error: "lint.out: unknown lint report format",
+		},
+		{
+			title: "malformed sarif",
+			body:  `{"runs": [}`,
+			error: "lint.out: invalid character '}' looking for beginning of value",
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "hub-comment")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lint.out")
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			body := strings.Replace(test.body, "ROOT", dir, -1)
+			assert.Nil(t, ioutil.WriteFile(path, []byte(body), 0644))
+
+			actual, err := ReadLint(path, dir)
+			if test.error != "" {
+				assert.EqualError(t, err, filepath.Join(dir, test.error))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestLintGroups(t *testing.T) {
+	lint := NewLint([]LintFinding{
+		{Rule: "golint", Severity: LintNote, File: "main.go", Line: 8},
+		{Rule: "errcheck", Severity: LintError, File: "main.go", Line: 3},
+		{Rule: "errcheck", Severity: LintWarning, File: "hub/context.go", Line: 10},
+	})
+
+	names := func(groups []LintGroup) []string {
+		var names []string
+		for _, group := range groups {
+			names = append(names, fmt.Sprintf("%s=%d", group.Name, len(group.Findings)))
+		}
+		return names
+	}
+
+	assert.Equal(t, 3, lint.Total)
+	assert.Equal(t, 1, lint.Errors)
+	assert.Equal(t, 1, lint.Warnings)
+	assert.Equal(t, 1, lint.Notes)
+	assert.Equal(t, []string{"hub/context.go=1", "main.go=2"}, names(lint.ByFile()))
+	assert.Equal(t, []string{"errcheck=2", "golint=1"}, names(lint.ByRule()))
+	assert.Equal(t, []string{"error=1", "warning=1", "note=1"}, names(lint.BySeverity()))
+}
+
+func TestLintTemplate(t *testing.T) {
+	tests := []struct {
+		title    string
+		build    map[string]string
+		expected string
+	}{
+		{
+			title: "no findings",
+			expected: "### ✅ No lint findings\n\n" +
+				"0 errors, 0 warnings, and 0 notes.",
+		},
+		{
+			title: "unknown repository",
+			build: map[string]string{},
+			expected: "### ❌ 2 lint findings\n\n" +
+				"1 error, 1 warning, and 0 notes.\n\n" +
+				"| Severity | Location | Rule | Message |\n" +
+				"|----------|----------|------|---------|\n" +
+				"| ❌ error | `hub/context.go:10` | errcheck | Error return value \\| not checked |\n" +
+				"| ⚠️ warning | `main.go` | golint | line one<br>line two |",
+		},
+		{
+			title: "known repository",
+			build: map[string]string{"Owner": "joshdk", "Repo": "hub-comment"},
+			expected: "### ❌ 2 lint findings\n\n" +
+				"1 error, 1 warning, and 0 notes.\n\n" +
+				"| Severity | Location | Rule | Message |\n" +
+				"|----------|----------|------|---------|\n" +
+				"| ❌ error | [`hub/context.go:10`](https://github.com/joshdk/hub-comment/blob/abc123/hub/context.go#L10) | errcheck | Error return value \\| not checked |\n" +
+				"| ⚠️ warning | [`main.go`](https://github.com/joshdk/hub-comment/blob/abc123/main.go) | golint | line one<br>line two |",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			actual := renderBuiltin(t, "lint", func(ctx *Context) {
+				if test.build != nil {
+					ctx.Build = test.build
+					ctx.Git = map[string]string{"SHA": "abc123"}
+					ctx.Lint = NewLint([]LintFinding{
+						{Rule: "golint", Severity: LintWarning, Message: "line one\nline two", File: "main.go"},
+						{Rule: "errcheck", Severity: LintError, Message: "Error return value | not checked", File: "hub/context.go", Line: 10},
+					})
+				}
+			})
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
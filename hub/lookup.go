@@ -0,0 +1,147 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	// pullsPerPage is the number of pull requests requested in a single page.
+	// This is the largest page size that the GitHub API allows.
+	pullsPerPage = 100
+
+	// maxPullPages is the maximum number of pages of open pull requests that
+	// will be searched through when looking for a matching commit.
+	maxPullPages = 10
+)
+
+// Commit identifies a commit in a repository, along with the branch that it
+// was built from.
+type Commit struct {
+	// Host is the host of the GitHub server, like "github.com". Empty if the
+	// server is not known.
+	Host string
+
+	// Owner is the user or organization that owns the repository.
+	Owner string
+
+	// Repo is the name of the repository.
+	Repo string
+
+	// SHA is the full commit hash.
+	SHA string
+
+	// Branch is the name of the branch that the commit was built from.
+	Branch string
+}
+
+// git runs the given git subcommand in the current working directory, and
+// returns its trimmed output. An empty string is returned if the command
+// fails, or if git is not installed. Replaced in tests.
+var git = func(args ...string) string {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// DetectCommit returns the commit currently being built. Values are taken from
+// the given CI provider, and any missing values are taken from the Git
+// repository in the current working directory. The returned bool is false if
+// either the repository, or both the SHA and branch, could not be found.
+func DetectCommit(env map[string]string, provider Provider) (Commit, bool) {
+	var (
+		build = provider.Build(env)
+		ref   = provider.Git(env)
+	)
+
+	commit := Commit{
+		Owner:  build["Owner"],
+		Repo:   build["Repo"],
+		SHA:    ref["SHA"],
+		Branch: ref["Branch"],
+	}
+
+	if res := reRepoURL.FindStringSubmatch(git("config", "--get", "remote.origin.url")); res != nil {
+		// Only trust the host of the Git remote if it agrees with the CI
+		// provider about which repository is being built.
+		if commit.Owner == "" && commit.Repo == "" || commit.Owner == res[2] && commit.Repo == strings.TrimSuffix(res[3], ".git") {
+			commit.Host = res[1]
+			commit.Owner = res[2]
+			commit.Repo = strings.TrimSuffix(res[3], ".git")
+		}
+	}
+
+	if commit.SHA == "" {
+		commit.SHA = git("rev-parse", "HEAD")
+	}
+
+	// A detached HEAD has an abbreviated ref name of literally "HEAD".
+	if commit.Branch == "" {
+		if branch := git("rev-parse", "--abbrev-ref", "HEAD"); branch != "HEAD" {
+			commit.Branch = branch
+		}
+	}
+
+	found := commit.Owner != "" && commit.Repo != "" && (commit.SHA != "" || commit.Branch != "")
+	return commit, found
+}
+
+// FindPullRequests returns references to every open pull request whose head
+// matches either the SHA or the branch of the given commit. Branches are only
+// matched against pull requests opened from the same repository, as branch
+// names from forks are not meaningful. References are ordered newest-first.
+func FindPullRequests(ctx context.Context, client *github.Client, commit Commit) ([]Reference, error) {
+	var (
+		refs = []Reference{}
+		slug = fmt.Sprintf("%s/%s", commit.Owner, commit.Repo)
+	)
+
+	opts := &github.PullRequestListOptions{
+		State:     "open",
+		Sort:      "created",
+		Direction: "desc",
+		ListOptions: github.ListOptions{
+			Page:    1,
+			PerPage: pullsPerPage,
+		},
+	}
+
+	for count := 0; opts.Page != 0 && count < maxPullPages; count++ {
+		pulls, resp, err := client.PullRequests.List(ctx, commit.Owner, commit.Repo, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pull := range pulls {
+			var (
+				head       = pull.GetHead()
+				sameSHA    = commit.SHA != "" && head.GetSHA() == commit.SHA
+				sameBranch = commit.Branch != "" && head.GetRef() == commit.Branch && strings.EqualFold(head.GetRepo().GetFullName(), slug)
+			)
+			if !sameSHA && !sameBranch {
+				continue
+			}
+
+			refs = append(refs, Reference{
+				Host:   commit.Host,
+				Owner:  commit.Owner,
+				Repo:   commit.Repo,
+				Number: pull.GetNumber(),
+			})
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	return refs, nil
+}
@@ -0,0 +1,204 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindPullRequests(t *testing.T) {
+	// newPull builds a pull request with the given head.
+	newPull := func(number int, slug string, ref string, sha string) *github.PullRequest {
+		return &github.PullRequest{
+			Number: github.Int(number),
+			Head: &github.PullRequestBranch{
+				Ref:  github.String(ref),
+				SHA:  github.String(sha),
+				Repo: &github.Repository{FullName: github.String(slug)},
+			},
+		}
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/joshdk/hub-comment/pulls", r.URL.Path)
+		assert.Equal(t, "open", r.URL.Query().Get("state"))
+
+		json.NewEncoder(w).Encode([]*github.PullRequest{
+			newPull(9, "joshdk/hub-comment", "feature", "c0ffee"),
+			newPull(8, "someone/hub-comment", "feature", "deadbeef"),
+			newPull(7, "joshdk/hub-comment", "other", "deadbeef"),
+			newPull(6, "someone/hub-comment", "fork", "c0ffee"),
+			newPull(5, "joshdk/hub-comment", "feature", "badf00d"),
+		})
+	})
+
+	tests := []struct {
+		title    string
+		commit   Commit
+		expected []int
+	}{
+		{
+			title:    "no matches",
+			commit:   Commit{Owner: "joshdk", Repo: "hub-comment", SHA: "0000000", Branch: "missing"},
+			expected: []int{},
+		},
+		{
+			title:    "sha only",
+			commit:   Commit{Owner: "joshdk", Repo: "hub-comment", SHA: "c0ffee"},
+			expected: []int{9, 6},
+		},
+		{
+			title:    "branch only",
+			commit:   Commit{Owner: "joshdk", Repo: "hub-comment", Branch: "feature"},
+			expected: []int{9, 5},
+		},
+		{
+			title:    "sha and branch",
+			commit:   Commit{Owner: "joshdk", Repo: "hub-comment", SHA: "deadbeef", Branch: "feature"},
+			expected: []int{9, 8, 7, 5},
+		},
+	}
+
+	client, server := newTestClient(handler)
+	defer server.Close()
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			refs, err := FindPullRequests(context.Background(), client, test.commit)
+			assert.Nil(t, err)
+
+			numbers := []int{}
+			for _, ref := range refs {
+				assert.Equal(t, "joshdk", ref.Owner)
+				assert.Equal(t, "hub-comment", ref.Repo)
+				numbers = append(numbers, ref.Number)
+			}
+			assert.Equal(t, test.expected, numbers)
+		})
+	}
+}
+
+func TestDetectCommit(t *testing.T) {
+	tests := []struct {
+		title    string
+		env      map[string]string
+		git      map[string]string
+		expected Commit
+		notFound bool
+	}{
+		{
+			title: "provider only",
+			env: map[string]string{
+				"CIRCLE_PROJECT_USERNAME": "joshdk",
+				"CIRCLE_PROJECT_REPONAME": "hub-comment",
+				"CIRCLE_SHA1":             "c0ffee",
+				"CIRCLE_BRANCH":           "feature",
+			},
+			expected: Commit{Owner: "joshdk", Repo: "hub-comment", SHA: "c0ffee", Branch: "feature"},
+		},
+		{
+			title: "https remote",
+			git: map[string]string{
+				"config --get remote.origin.url": "https://github.com/joshdk/hub-comment.git",
+				"rev-parse HEAD":                 "c0ffee",
+				"rev-parse --abbrev-ref HEAD":    "feature",
+			},
+			expected: Commit{Host: "github.com", Owner: "joshdk", Repo: "hub-comment", SHA: "c0ffee", Branch: "feature"},
+		},
+		{
+			title: "ssh remote",
+			git: map[string]string{
+				"config --get remote.origin.url": "git@github.example.com:joshdk/hub-comment.git",
+				"rev-parse HEAD":                 "c0ffee",
+			},
+			expected: Commit{Host: "github.example.com", Owner: "joshdk", Repo: "hub-comment", SHA: "c0ffee"},
+		},
+		{
+			title: "ssh remote with port",
+			git: map[string]string{
+				"config --get remote.origin.url": "ssh://git@github.example.com:2222/joshdk/hub-comment",
+				"rev-parse HEAD":                 "c0ffee",
+			},
+			expected: Commit{Host: "github.example.com", Owner: "joshdk", Repo: "hub-comment", SHA: "c0ffee"},
+		},
+		{
+			title: "remote agrees with provider",
+			env: map[string]string{
+				"CIRCLE_PROJECT_USERNAME": "joshdk",
+				"CIRCLE_PROJECT_REPONAME": "hub-comment",
+				"CIRCLE_SHA1":             "c0ffee",
+			},
+			git: map[string]string{
+				"config --get remote.origin.url": "https://github.example.com/joshdk/hub-comment",
+				"rev-parse HEAD":                 "deadbeef",
+				"rev-parse --abbrev-ref HEAD":    "feature",
+			},
+			expected: Commit{Host: "github.example.com", Owner: "joshdk", Repo: "hub-comment", SHA: "c0ffee", Branch: "feature"},
+		},
+		{
+			title: "remote disagrees with provider",
+			env: map[string]string{
+				"CIRCLE_PROJECT_USERNAME": "joshdk",
+				"CIRCLE_PROJECT_REPONAME": "hub-comment",
+				"CIRCLE_SHA1":             "c0ffee",
+			},
+			git: map[string]string{
+				"config --get remote.origin.url": "https://github.example.com/someone/fork",
+			},
+			expected: Commit{Owner: "joshdk", Repo: "hub-comment", SHA: "c0ffee"},
+		},
+		{
+			title: "detached head",
+			git: map[string]string{
+				"config --get remote.origin.url": "https://github.com/joshdk/hub-comment",
+				"rev-parse HEAD":                 "c0ffee",
+				"rev-parse --abbrev-ref HEAD":    "HEAD",
+			},
+			expected: Commit{Host: "github.com", Owner: "joshdk", Repo: "hub-comment", SHA: "c0ffee"},
+		},
+		{
+			title: "unknown remote",
+			git: map[string]string{
+				"config --get remote.origin.url": "/srv/git/hub-comment",
+				"rev-parse HEAD":                 "c0ffee",
+			},
+			expected: Commit{SHA: "c0ffee"},
+			notFound: true,
+		},
+		{
+			title: "no repository",
+			env: map[string]string{
+				"CIRCLE_PROJECT_USERNAME": "joshdk",
+				"CIRCLE_PROJECT_REPONAME": "hub-comment",
+			},
+			expected: Commit{Owner: "joshdk", Repo: "hub-comment"},
+			notFound: true,
+		},
+	}
+
+	defer func(original func(...string) string) { git = original }(git)
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			git = func(args ...string) string {
+				return test.git[strings.Join(args, " ")]
+			}
+
+			actual, found := DetectCommit(test.env, circleCI{})
+			assert.Equal(t, !test.notFound, found)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	// metaVersion is the current version of the metadata block format.
+	// Increment when changing the meaning of an existing field.
+	metaVersion = 1
+
+	// metaBlockPrefix and metaBlockSuffix surround the JSON encoded metadata
+	// block that is written as the first line of every posted comment. An
+	// HTML comment is used so that the block is hidden when rendered.
+	metaBlockPrefix = "<!-- hub-comment "
+	metaBlockSuffix = " -->"
+
+	// metaTypePrefix is the prefix of the line used to record the comment
+	// type, before the metadata block was introduced.
+	metaTypePrefix = "[//]: # (meta:type="
+)
+
+// clock returns the current time, for use in timestamps. Replaced in tests.
+var clock = time.Now
+
+// Metadata represents the hidden, machine readable, block of information at
+// the top of every posted comment.
+type Metadata struct {
+	// Version is the version of the metadata block format. Zero for comments
+	// that predate the metadata block.
+	Version int `json:"v"`
+
+	// Type is the comment type, as given by the -type flag.
+	Type string `json:"type"`
+
+	// Tool is the version of hub-comment that posted the comment.
+	Tool string `json:"tool,omitempty"`
+
+	// Build is the CI build number that posted the comment.
+	Build string `json:"build,omitempty"`
+
+	// SHA is the commit that was being built when the comment was posted.
+	SHA string `json:"sha,omitempty"`
+
+	// Hash is the SHA-256 hash of the most recently rendered comment content,
+	// excluding the metadata block itself, and any earlier renders kept by
+	// the append, prepend, or history modes.
+	Hash string `json:"hash,omitempty"`
+
+	// Time is when the comment was rendered.
+	Time time.Time `json:"time"`
+
+	// Coverage is the total coverage percentage reported by the comment, if
+	// any.
+	Coverage *float64 `json:"coverage,omitempty"`
+}
+
+// NewMetadata returns the metadata block for a comment with the given content,
+// rendered from the given context.
+func NewMetadata(ctx *Context, content string) Metadata {
+	meta := Metadata{
+		Version: metaVersion,
+		Type:    ctx.Meta["Type"],
+		Tool:    ctx.Meta["Version"],
+		Build:   ctx.Build["Number"],
+		SHA:     ctx.Git["SHA"],
+		Hash:    HashContent(content),
+		Time:    clock().UTC().Truncate(time.Second),
+	}
+	if ctx.Coverage != nil && ctx.Coverage.Statements > 0 {
+		total := ctx.Coverage.Total
+		meta.Coverage = &total
+	}
+	return meta
+}
+
+// String returns the metadata block as a single line, for use as the first
+// line of a comment.
+func (m Metadata) String() string {
+	// The encoder escapes "<" and ">", so the block can never contain the
+	// end of the HTML comment.
+	body, _ := json.Marshal(m)
+	return metaBlockPrefix + string(body) + metaBlockSuffix
+}
+
+// HashContent returns the hex encoded SHA-256 hash of the given comment
+// content.
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Unchanged returns true if the given comment has the same content as the
+// existing comment, according to the hashes recorded in their metadata. The
+// content of comments that predate the hash is hashed instead. Comments that
+// record a different coverage are always changed, so that the next comparison
+// is made against the latest coverage.
+func Unchanged(existing string, comment string) bool {
+	old, content, found := ParseComment(existing)
+	if !found {
+		return false
+	}
+	if old.Hash == "" {
+		old.Hash = HashContent(content)
+	}
+
+	meta, _, _ := ParseComment(comment)
+	switch {
+	case old.Hash != meta.Hash:
+		return false
+	case old.Coverage == nil || meta.Coverage == nil:
+		return old.Coverage == meta.Coverage
+	default:
+		return *old.Coverage == *meta.Coverage
+	}
+}
+
+// ParseComment splits the given comment body into its metadata and its
+// content. Only the leading lines of the comment are searched, so that quoting
+// another comment does not change the type of this one. Comments posted before
+// the metadata block was introduced are also recognized. The returned bool is
+// false if the comment has no metadata, in which case the whole body is
+// returned as content.
+func ParseComment(comment string) (Metadata, string, bool) {
+	var (
+		meta  Metadata
+		found bool
+		lines = strings.Split(comment, "\n")
+		index = 0
+	)
+
+	for ; index < len(lines); index++ {
+		line := strings.TrimRight(lines[index], "\r")
+		switch {
+		case strings.HasPrefix(line, metaBlockPrefix) && strings.HasSuffix(line, metaBlockSuffix):
+			body := line[len(metaBlockPrefix) : len(line)-len(metaBlockSuffix)]
+			if err := json.Unmarshal([]byte(body), &meta); err != nil {
+				return Metadata{}, comment, false
+			}
+			found = true
+			continue
+		case strings.HasPrefix(line, metaTypePrefix) && strings.HasSuffix(line, ")"):
+			meta.Type = line[len(metaTypePrefix) : len(line)-1]
+			found = true
+			continue
+		}
+		break
+	}
+
+	if !found {
+		return Metadata{}, comment, false
+	}
+	return meta, strings.TrimLeft(strings.Join(lines[index:], "\n"), "\r\n"), true
+}
@@ -0,0 +1,207 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// withoutMetadata returns the content of the given comment, after checking
+// that it starts with a metadata block of the given type.
+func withoutMetadata(t *testing.T, typeName string, comment string) string {
+	meta, content, found := ParseComment(comment)
+	assert.True(t, found)
+	assert.Equal(t, typeName, meta.Type)
+	assert.Equal(t, HashContent(content), meta.Hash)
+	return content
+}
+
+// renderBuiltin renders the named built-in template, with a context of the
+// same type that has been prepared by setup, and returns the content of the
+// resulting comment.
+func renderBuiltin(t *testing.T, typeName string, setup func(*Context)) string {
+	file, found := BuiltinTemplate(typeName)
+	assert.True(t, found)
+
+	tpl, cf, err := NewTemplate([]TemplateFile{file}, "")
+	assert.Nil(t, err)
+
+	ctx := NewContext(nil, map[string]string{}, circleCI{}, nil, &github.Issue{}, typeName)
+	setup(ctx)
+
+	actual, err := Execute(tpl, ctx, cf)
+	assert.Nil(t, err)
+	return withoutMetadata(t, typeName, actual)
+}
+
+func TestParseComment(t *testing.T) {
+	tests := []struct {
+		title    string
+		comment  string
+		meta     Metadata
+		content  string
+		notFound bool
+	}{
+		{
+			title:    "empty comment",
+			notFound: true,
+		},
+		{
+			title:    "no metadata",
+			comment:  "Hello\n\nWorld",
+			content:  "Hello\n\nWorld",
+			notFound: true,
+		},
+		{
+			title:   "metadata block",
+			comment: `<!-- hub-comment {"v":1,"type":"lint","sha":"abc123","time":"2018-09-14T00:00:00Z"} -->` + "\n\nHello\n\nWorld",
+			meta: Metadata{
+				Version: 1,
+				Type:    "lint",
+				SHA:     "abc123",
+				Time:    time.Date(2018, 9, 14, 0, 0, 0, 0, time.UTC),
+			},
+			content: "Hello\n\nWorld",
+		},
+		{
+			title:   "unknown fields",
+			comment: `<!-- hub-comment {"v":2,"type":"lint","future":true} -->` + "\r\n\r\nHello",
+			meta:    Metadata{Version: 2, Type: "lint"},
+			content: "Hello",
+		},
+		{
+			title:    "malformed metadata block",
+			comment:  `<!-- hub-comment {"v":1,"type":} -->` + "\n\nHello",
+			content:  `<!-- hub-comment {"v":1,"type":} -->` + "\n\nHello",
+			notFound: true,
+		},
+		{
+			title:   "legacy type",
+			comment: "[//]: # (meta:type=tests)\n\nHello",
+			meta:    Metadata{Type: "tests"},
+			content: "Hello",
+		},
+		{
+			title:    "quoted metadata",
+			comment:  "> [//]: # (meta:type=tests)\n\n```\n[//]: # (meta:type=tests)\n```",
+			content:  "> [//]: # (meta:type=tests)\n\n```\n[//]: # (meta:type=tests)\n```",
+			notFound: true,
+		},
+		{
+			title:    "metadata after content",
+			comment:  "Hello\n<!-- hub-comment {\"v\":1,\"type\":\"lint\"} -->",
+			content:  "Hello\n<!-- hub-comment {\"v\":1,\"type\":\"lint\"} -->",
+			notFound: true,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			meta, content, found := ParseComment(test.comment)
+			assert.Equal(t, !test.notFound, found)
+			assert.Equal(t, test.meta, meta)
+			assert.Equal(t, test.content, content)
+		})
+	}
+}
+
+func TestNewMetadata(t *testing.T) {
+	defer func(original func() time.Time) { clock = original }(clock)
+	clock = func() time.Time {
+		return time.Date(2018, 9, 14, 1, 2, 3, 456, time.FixedZone("EST", -5*60*60))
+	}
+
+	env := map[string]string{
+		"CIRCLE_BUILD_NUM": "42",
+		"CIRCLE_SHA1":      "abc123",
+	}
+	ctx := NewContext(env, env, circleCI{}, nil, &github.Issue{}, "coverage")
+	ctx.Meta["Version"] = "v1.2.3"
+	ctx.Coverage = &Coverage{Statements: 10, Covered: 5, Total: 50}
+
+	meta := NewMetadata(ctx, "<b>Hello</b> -->")
+	expected := `<!-- hub-comment {"v":1,"type":"coverage","tool":"v1.2.3","build":"42","sha":"abc123",` +
+		`"hash":"` + HashContent("<b>Hello</b> -->") + `","time":"2018-09-14T06:02:03Z","coverage":50} -->`
+	assert.Equal(t, expected, meta.String())
+
+	parsed, content, found := ParseComment(meta.String() + "\n\n<b>Hello</b> -->")
+	assert.True(t, found)
+	assert.Equal(t, meta, parsed)
+	assert.Equal(t, "<b>Hello</b> -->", content)
+}
+
+func TestUnchanged(t *testing.T) {
+	var (
+		hello = HashContent("Hello")
+		block = func(hash string, coverage string) string {
+			return `<!-- hub-comment {"v":1,"type":"default","hash":"` + hash + `"` + coverage + `} -->`
+		}
+	)
+
+	tests := []struct {
+		title     string
+		existing  string
+		comment   string
+		unchanged bool
+	}{
+		{
+			title:    "no existing metadata",
+			existing: "Hello",
+			comment:  block(hello, "") + "\n\nHello",
+		},
+		{
+			title:     "same hash",
+			existing:  block(hello, "") + "\n\nHello",
+			comment:   block(hello, "") + "\n\nHello",
+			unchanged: true,
+		},
+		{
+			title:    "different hash",
+			existing: block(hello, "") + "\n\nHello",
+			comment:  block(HashContent("World"), "") + "\n\nWorld",
+		},
+		{
+			title:     "edited by hand",
+			existing:  block(hello, "") + "\n\nHello, edited",
+			comment:   block(hello, "") + "\n\nHello",
+			unchanged: true,
+		},
+		{
+			title:     "legacy comment",
+			existing:  "[//]: # (meta:type=default)\n\nHello",
+			comment:   block(hello, "") + "\n\nHello",
+			unchanged: true,
+		},
+		{
+			title:     "same coverage",
+			existing:  block(hello, `,"coverage":50`) + "\n\nHello",
+			comment:   block(hello, `,"coverage":50`) + "\n\nHello",
+			unchanged: true,
+		},
+		{
+			title:    "different coverage",
+			existing: block(hello, `,"coverage":50`) + "\n\nHello",
+			comment:  block(hello, `,"coverage":51`) + "\n\nHello",
+		},
+		{
+			title:    "new coverage",
+			existing: block(hello, "") + "\n\nHello",
+			comment:  block(hello, `,"coverage":51`) + "\n\nHello",
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.unchanged, Unchanged(test.existing, test.comment))
+		})
+	}
+}
@@ -0,0 +1,510 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Provider represents a CI system that hub-comment might be running inside of.
+// Each provider knows how to detect itself from the environment, and how to
+// translate its own environment variables into the common set of Build and Git
+// parameters that are exposed to comment templates.
+type Provider interface {
+	// Name returns a human readable name for the CI system.
+	Name() string
+
+	// Detect returns true if the given environment belongs to the CI system.
+	Detect(env map[string]string) bool
+
+	// Reference returns a link to the current pull request, if one exists.
+	Reference(env map[string]string) (string, bool)
+
+	// Build returns a map of CI specific parameters. The keys "CI", "Index",
+	// "Job", "Nodes", "Number", "Owner", "Repo", "Stage", "URL", "User", and
+	// "Workflow" are always populated.
+	Build(env map[string]string) map[string]string
+
+	// Git returns a map of Git specific parameters. The keys "Branch", "PR",
+	// "SHA", and "Tag" are always populated.
+	Git(env map[string]string) map[string]string
+}
+
+// providers is the list of all known CI providers, in the order that they are
+// checked during detection.
+var providers = []Provider{
+	circleCI{},
+	githubActions{},
+	travisCI{},
+	jenkins{},
+	buildkite{},
+	drone{},
+	gitlabCI{},
+}
+
+// DetectProvider returns the CI provider that matches the given environment.
+// CircleCI is returned if no other provider could be detected, as it was the
+// only supported provider historically.
+func DetectProvider(env map[string]string) Provider {
+	for _, provider := range providers {
+		if provider.Detect(env) {
+			return provider
+		}
+	}
+	return circleCI{}
+}
+
+var (
+	// reRepoURL is a regex intended to match Git remote URLs that look like
+	// "https://github.com/joshdk/hub-comment.git" or
+	// "git@github.com:joshdk/hub-comment.git", and capture the host, owner,
+	// and repo.
+	reRepoURL = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?([^:/]+)(?::\d+)?[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+)
+
+// splitSlug splits a repository slug like "joshdk/hub-comment" into the owner
+// and repo.
+func splitSlug(slug string) (string, string) {
+	pieces := strings.SplitN(slug, "/", 2)
+	if len(pieces) != 2 {
+		return "", ""
+	}
+	return pieces[0], pieces[1]
+}
+
+// pullLink builds a link to the given pull request number. An empty string is
+// returned if any of the components are missing.
+func pullLink(host string, owner string, repo string, number string) string {
+	if host == "" || owner == "" || repo == "" {
+		return ""
+	}
+	if n, err := strconv.Atoi(number); err != nil || n <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://%s/%s/%s/pull/%s", host, owner, repo, number)
+}
+
+// hostOf returns the host portion of the given URL, or fallback if the URL has
+// no recognizable host.
+func hostOf(link string, fallback string) string {
+	link = strings.TrimPrefix(strings.TrimPrefix(link, "https://"), "http://")
+	if index := strings.Index(link, "/"); index >= 0 {
+		link = link[:index]
+	}
+	return first(link, fallback)
+}
+
+// first returns the first of the given values that is not empty.
+func first(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// reference is a helper for implementing Provider.Reference. It returns the
+// given link, and whether that link was non-empty.
+func reference(link string) (string, bool) {
+	return link, link != ""
+}
+
+// decrement returns the given number string minus one. Used for converting
+// 1-based node indexes into 0-based ones.
+func decrement(number string, fallback string) string {
+	n, err := strconv.Atoi(number)
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return strconv.Itoa(n - 1)
+}
+
+// circleCI is the Provider for CircleCI.
+//
+// See https://circleci.com/docs/2.0/env-vars/#built-in-environment-variables
+type circleCI struct{}
+
+func (circleCI) Name() string {
+	return "CircleCI"
+}
+
+func (circleCI) Detect(env map[string]string) bool {
+	return get(env, "CIRCLECI") == "true"
+}
+
+func (circleCI) Reference(env map[string]string) (string, bool) {
+	return reference(get(env, "CIRCLE_PULL_REQUEST"))
+}
+
+func (circleCI) Build(env map[string]string) map[string]string {
+	return map[string]string{
+		"CI":       get(env, "CIRCLECI"),
+		"Index":    get(env, "CIRCLE_NODE_INDEX", "0"),
+		"Job":      get(env, "CIRCLE_JOB"),
+		"Nodes":    get(env, "CIRCLE_NODE_TOTAL", "1"),
+		"Number":   get(env, "CIRCLE_BUILD_NUM"),
+		"Owner":    get(env, "CIRCLE_PROJECT_USERNAME"),
+		"Repo":     get(env, "CIRCLE_PROJECT_REPONAME"),
+		"Stage":    get(env, "CIRCLE_STAGE"),
+		"URL":      get(env, "CIRCLE_BUILD_URL"),
+		"User":     get(env, "CIRCLE_USERNAME"),
+		"Workflow": get(env, "CIRCLE_WORKFLOW_ID"),
+	}
+}
+
+func (circleCI) Git(env map[string]string) map[string]string {
+	return map[string]string{
+		"Branch": get(env, "CIRCLE_BRANCH"),
+		"PR":     get(env, "CIRCLE_PULL_REQUEST"),
+		"SHA":    get(env, "CIRCLE_SHA1"),
+		"Tag":    get(env, "CIRCLE_TAG"),
+	}
+}
+
+// githubActions is the Provider for GitHub Actions.
+//
+// See https://docs.github.com/en/actions/learn-github-actions/variables#default-environment-variables
+type githubActions struct{}
+
+// rePullRef is a regex intended to match Git refs that look like
+// "refs/pull/123/merge", and capture the pull request number.
+var rePullRef = regexp.MustCompile(`^refs/pull/([1-9]\d*)/`)
+
+func (githubActions) Name() string {
+	return "GitHub Actions"
+}
+
+func (githubActions) Detect(env map[string]string) bool {
+	return get(env, "GITHUB_ACTIONS") == "true"
+}
+
+func (githubActions) Reference(env map[string]string) (string, bool) {
+	// Prefer the event payload, as it describes the pull request for a wider
+	// variety of events than GITHUB_REF does.
+	if path := get(env, "GITHUB_EVENT_PATH"); path != "" {
+		if body, err := ioutil.ReadFile(path); err == nil {
+			if link, found := eventReference(get(env, "GITHUB_EVENT_NAME"), body, get(env, "GITHUB_SERVER_URL")); found {
+				return link, true
+			}
+		}
+	}
+
+	var number string
+	if res := rePullRef.FindStringSubmatch(get(env, "GITHUB_REF")); res != nil {
+		number = res[1]
+	}
+
+	var (
+		host        = hostOf(get(env, "GITHUB_SERVER_URL"), "github.com")
+		owner, repo = splitSlug(get(env, "GITHUB_REPOSITORY"))
+	)
+	return reference(pullLink(host, owner, repo, number))
+}
+
+func (githubActions) Build(env map[string]string) map[string]string {
+	var (
+		server      = get(env, "GITHUB_SERVER_URL", "https://github.com")
+		slug        = get(env, "GITHUB_REPOSITORY")
+		owner, repo = splitSlug(slug)
+		url         string
+	)
+	if slug != "" && get(env, "GITHUB_RUN_ID") != "" {
+		url = fmt.Sprintf("%s/%s/actions/runs/%s", server, slug, get(env, "GITHUB_RUN_ID"))
+	}
+
+	return map[string]string{
+		"CI":       get(env, "CI"),
+		"Index":    "0",
+		"Job":      get(env, "GITHUB_JOB"),
+		"Nodes":    "1",
+		"Number":   get(env, "GITHUB_RUN_NUMBER"),
+		"Owner":    owner,
+		"Repo":     repo,
+		"Stage":    get(env, "GITHUB_WORKFLOW"),
+		"URL":      url,
+		"User":     get(env, "GITHUB_ACTOR"),
+		"Workflow": get(env, "GITHUB_RUN_ID"),
+	}
+}
+
+func (p githubActions) Git(env map[string]string) map[string]string {
+	var (
+		pr, _  = p.Reference(env)
+		branch = get(env, "GITHUB_HEAD_REF")
+		tag    string
+	)
+
+	// The GITHUB_HEAD_REF variable is only set for pull request events. In
+	// all other cases, the ref name describes either the branch or the tag.
+	switch {
+	case get(env, "GITHUB_REF_TYPE") == "tag":
+		tag = get(env, "GITHUB_REF_NAME")
+	case branch == "":
+		branch = strings.TrimPrefix(get(env, "GITHUB_REF"), "refs/heads/")
+	}
+
+	return map[string]string{
+		"Branch": branch,
+		"PR":     pr,
+		"SHA":    get(env, "GITHUB_SHA"),
+		"Tag":    tag,
+	}
+}
+
+// travisCI is the Provider for Travis CI.
+//
+// See https://docs.travis-ci.com/user/environment-variables/#default-environment-variables
+type travisCI struct{}
+
+func (travisCI) Name() string {
+	return "Travis CI"
+}
+
+func (travisCI) Detect(env map[string]string) bool {
+	return get(env, "TRAVIS") == "true"
+}
+
+func (travisCI) Reference(env map[string]string) (string, bool) {
+	// TRAVIS_PULL_REQUEST is literally "false" for non-pr builds, which will be
+	// rejected as it is not a number.
+	owner, repo := splitSlug(get(env, "TRAVIS_REPO_SLUG"))
+	return reference(pullLink("github.com", owner, repo, get(env, "TRAVIS_PULL_REQUEST")))
+}
+
+func (travisCI) Build(env map[string]string) map[string]string {
+	owner, repo := splitSlug(get(env, "TRAVIS_REPO_SLUG"))
+
+	return map[string]string{
+		"CI":       get(env, "CI"),
+		"Index":    "0",
+		"Job":      get(env, "TRAVIS_JOB_NAME"),
+		"Nodes":    "1",
+		"Number":   get(env, "TRAVIS_BUILD_NUMBER"),
+		"Owner":    owner,
+		"Repo":     repo,
+		"Stage":    get(env, "TRAVIS_BUILD_STAGE_NAME"),
+		"URL":      get(env, "TRAVIS_BUILD_WEB_URL"),
+		"User":     "",
+		"Workflow": get(env, "TRAVIS_BUILD_ID"),
+	}
+}
+
+func (p travisCI) Git(env map[string]string) map[string]string {
+	pr, _ := p.Reference(env)
+
+	// For pull request builds, TRAVIS_BRANCH and TRAVIS_COMMIT describe the
+	// target branch and merge commit, rather than the pull request head.
+	return map[string]string{
+		"Branch": first(get(env, "TRAVIS_PULL_REQUEST_BRANCH"), get(env, "TRAVIS_BRANCH")),
+		"PR":     pr,
+		"SHA":    first(get(env, "TRAVIS_PULL_REQUEST_SHA"), get(env, "TRAVIS_COMMIT")),
+		"Tag":    get(env, "TRAVIS_TAG"),
+	}
+}
+
+// jenkins is the Provider for Jenkins, using the GitHub Branch Source plugin.
+//
+// See https://www.jenkins.io/doc/book/pipeline/multibranch/#additional-environment-variables
+type jenkins struct{}
+
+func (jenkins) Name() string {
+	return "Jenkins"
+}
+
+func (jenkins) Detect(env map[string]string) bool {
+	return get(env, "JENKINS_URL") != ""
+}
+
+func (jenkins) Reference(env map[string]string) (string, bool) {
+	return reference(get(env, "CHANGE_URL"))
+}
+
+func (jenkins) Build(env map[string]string) map[string]string {
+	var owner, repo string
+	if res := reRepoURL.FindStringSubmatch(get(env, "GIT_URL")); res != nil {
+		owner, repo = res[2], res[3]
+	}
+
+	return map[string]string{
+		"CI":       "true",
+		"Index":    "0",
+		"Job":      get(env, "JOB_NAME"),
+		"Nodes":    "1",
+		"Number":   get(env, "BUILD_NUMBER"),
+		"Owner":    owner,
+		"Repo":     repo,
+		"Stage":    get(env, "STAGE_NAME"),
+		"URL":      get(env, "BUILD_URL"),
+		"User":     get(env, "CHANGE_AUTHOR"),
+		"Workflow": get(env, "BUILD_TAG"),
+	}
+}
+
+func (jenkins) Git(env map[string]string) map[string]string {
+	return map[string]string{
+		"Branch": first(get(env, "CHANGE_BRANCH"), get(env, "BRANCH_NAME"), get(env, "GIT_BRANCH")),
+		"PR":     get(env, "CHANGE_URL"),
+		"SHA":    get(env, "GIT_COMMIT"),
+		"Tag":    get(env, "TAG_NAME"),
+	}
+}
+
+// buildkite is the Provider for Buildkite.
+//
+// See https://buildkite.com/docs/pipelines/environment-variables
+type buildkite struct{}
+
+func (buildkite) Name() string {
+	return "Buildkite"
+}
+
+func (buildkite) Detect(env map[string]string) bool {
+	return get(env, "BUILDKITE") == "true"
+}
+
+func (buildkite) Reference(env map[string]string) (string, bool) {
+	res := reRepoURL.FindStringSubmatch(get(env, "BUILDKITE_REPO"))
+	if res == nil {
+		return "", false
+	}
+	// BUILDKITE_PULL_REQUEST is literally "false" for non-pr builds, which
+	// will be rejected as it is not a number.
+	return reference(pullLink(res[1], res[2], res[3], get(env, "BUILDKITE_PULL_REQUEST")))
+}
+
+func (buildkite) Build(env map[string]string) map[string]string {
+	var owner, repo string
+	if res := reRepoURL.FindStringSubmatch(get(env, "BUILDKITE_REPO")); res != nil {
+		owner, repo = res[2], res[3]
+	}
+
+	return map[string]string{
+		"CI":       get(env, "CI"),
+		"Index":    get(env, "BUILDKITE_PARALLEL_JOB", "0"),
+		"Job":      get(env, "BUILDKITE_LABEL"),
+		"Nodes":    get(env, "BUILDKITE_PARALLEL_JOB_COUNT", "1"),
+		"Number":   get(env, "BUILDKITE_BUILD_NUMBER"),
+		"Owner":    owner,
+		"Repo":     repo,
+		"Stage":    get(env, "BUILDKITE_STEP_KEY"),
+		"URL":      get(env, "BUILDKITE_BUILD_URL"),
+		"User":     get(env, "BUILDKITE_BUILD_CREATOR"),
+		"Workflow": get(env, "BUILDKITE_BUILD_ID"),
+	}
+}
+
+func (p buildkite) Git(env map[string]string) map[string]string {
+	pr, _ := p.Reference(env)
+
+	return map[string]string{
+		"Branch": get(env, "BUILDKITE_BRANCH"),
+		"PR":     pr,
+		"SHA":    get(env, "BUILDKITE_COMMIT"),
+		"Tag":    get(env, "BUILDKITE_TAG"),
+	}
+}
+
+// drone is the Provider for Drone.
+//
+// See https://docs.drone.io/pipeline/environment/reference/
+type drone struct{}
+
+func (drone) Name() string {
+	return "Drone"
+}
+
+func (drone) Detect(env map[string]string) bool {
+	return get(env, "DRONE") == "true"
+}
+
+func (drone) Reference(env map[string]string) (string, bool) {
+	var (
+		host        = hostOf(get(env, "DRONE_REPO_LINK"), "github.com")
+		owner, repo = splitSlug(get(env, "DRONE_REPO"))
+	)
+	return reference(pullLink(host, owner, repo, get(env, "DRONE_PULL_REQUEST")))
+}
+
+func (drone) Build(env map[string]string) map[string]string {
+	return map[string]string{
+		"CI":       get(env, "CI"),
+		"Index":    "0",
+		"Job":      get(env, "DRONE_STEP_NAME"),
+		"Nodes":    "1",
+		"Number":   get(env, "DRONE_BUILD_NUMBER"),
+		"Owner":    get(env, "DRONE_REPO_OWNER"),
+		"Repo":     get(env, "DRONE_REPO_NAME"),
+		"Stage":    get(env, "DRONE_STAGE_NAME"),
+		"URL":      get(env, "DRONE_BUILD_LINK"),
+		"User":     get(env, "DRONE_COMMIT_AUTHOR"),
+		"Workflow": "",
+	}
+}
+
+func (p drone) Git(env map[string]string) map[string]string {
+	pr, _ := p.Reference(env)
+
+	return map[string]string{
+		"Branch": first(get(env, "DRONE_SOURCE_BRANCH"), get(env, "DRONE_BRANCH")),
+		"PR":     pr,
+		"SHA":    get(env, "DRONE_COMMIT_SHA"),
+		"Tag":    get(env, "DRONE_TAG"),
+	}
+}
+
+// gitlabCI is the Provider for GitLab CI, running against a repository that is
+// mirrored from GitHub.
+//
+// See https://docs.gitlab.com/ee/ci/ci_cd_for_external_repos/#pipelines-for-external-pull-requests
+type gitlabCI struct{}
+
+func (gitlabCI) Name() string {
+	return "GitLab CI"
+}
+
+func (gitlabCI) Detect(env map[string]string) bool {
+	return get(env, "GITLAB_CI") == "true"
+}
+
+func (gitlabCI) Reference(env map[string]string) (string, bool) {
+	owner, repo := splitSlug(get(env, "CI_EXTERNAL_PULL_REQUEST_TARGET_REPOSITORY"))
+	return reference(pullLink("github.com", owner, repo, get(env, "CI_EXTERNAL_PULL_REQUEST_IID")))
+}
+
+func (gitlabCI) Build(env map[string]string) map[string]string {
+	// Prefer the mirrored GitHub repository, but fallback to the GitLab
+	// project for non-pr pipelines.
+	owner, repo := splitSlug(first(get(env, "CI_EXTERNAL_PULL_REQUEST_TARGET_REPOSITORY"), get(env, "CI_PROJECT_PATH")))
+
+	return map[string]string{
+		"CI":       get(env, "CI"),
+		"Index":    decrement(get(env, "CI_NODE_INDEX"), "0"),
+		"Job":      get(env, "CI_JOB_NAME"),
+		"Nodes":    get(env, "CI_NODE_TOTAL", "1"),
+		"Number":   get(env, "CI_PIPELINE_IID"),
+		"Owner":    owner,
+		"Repo":     repo,
+		"Stage":    get(env, "CI_JOB_STAGE"),
+		"URL":      get(env, "CI_PIPELINE_URL"),
+		"User":     get(env, "GITLAB_USER_LOGIN"),
+		"Workflow": get(env, "CI_PIPELINE_ID"),
+	}
+}
+
+func (p gitlabCI) Git(env map[string]string) map[string]string {
+	pr, _ := p.Reference(env)
+
+	return map[string]string{
+		"Branch": first(get(env, "CI_EXTERNAL_PULL_REQUEST_SOURCE_BRANCH_NAME"), get(env, "CI_COMMIT_BRANCH")),
+		"PR":     pr,
+		"SHA":    first(get(env, "CI_EXTERNAL_PULL_REQUEST_SOURCE_BRANCH_SHA"), get(env, "CI_COMMIT_SHA")),
+		"Tag":    get(env, "CI_COMMIT_TAG"),
+	}
+}
@@ -0,0 +1,391 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectProvider(t *testing.T) {
+	tests := []struct {
+		title     string
+		env       map[string]string
+		name      string
+		reference string
+		build     map[string]string
+		git       map[string]string
+	}{
+		{
+			title: "no environment",
+			name:  "CircleCI",
+			build: map[string]string{
+				"CI":       "",
+				"Index":    "0",
+				"Job":      "",
+				"Nodes":    "1",
+				"Number":   "",
+				"Owner":    "",
+				"Repo":     "",
+				"Stage":    "",
+				"URL":      "",
+				"User":     "",
+				"Workflow": "",
+			},
+			git: map[string]string{
+				"Branch": "",
+				"PR":     "",
+				"SHA":    "",
+				"Tag":    "",
+			},
+		},
+		{
+			title: "circleci",
+			env: map[string]string{
+				"CIRCLECI":                "true",
+				"CIRCLE_BRANCH":           "feature",
+				"CIRCLE_BUILD_NUM":        "42",
+				"CIRCLE_BUILD_URL":        "https://circleci.com/gh/joshdk/hub-comment/42",
+				"CIRCLE_JOB":              "build",
+				"CIRCLE_NODE_INDEX":       "2",
+				"CIRCLE_NODE_TOTAL":       "4",
+				"CIRCLE_PROJECT_REPONAME": "hub-comment",
+				"CIRCLE_PROJECT_USERNAME": "joshdk",
+				"CIRCLE_PULL_REQUEST":     "https://github.com/joshdk/hub-comment/pull/123",
+				"CIRCLE_SHA1":             "c0ffee",
+				"CIRCLE_USERNAME":         "joshdk",
+				"CIRCLE_WORKFLOW_ID":      "a1b2c3",
+			},
+			name:      "CircleCI",
+			reference: "https://github.com/joshdk/hub-comment/pull/123",
+			build: map[string]string{
+				"CI":       "true",
+				"Index":    "2",
+				"Job":      "build",
+				"Nodes":    "4",
+				"Number":   "42",
+				"Owner":    "joshdk",
+				"Repo":     "hub-comment",
+				"Stage":    "",
+				"URL":      "https://circleci.com/gh/joshdk/hub-comment/42",
+				"User":     "joshdk",
+				"Workflow": "a1b2c3",
+			},
+			git: map[string]string{
+				"Branch": "feature",
+				"PR":     "https://github.com/joshdk/hub-comment/pull/123",
+				"SHA":    "c0ffee",
+				"Tag":    "",
+			},
+		},
+		{
+			title: "github actions",
+			env: map[string]string{
+				"CI":                "true",
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_ACTOR":      "joshdk",
+				"GITHUB_HEAD_REF":   "feature",
+				"GITHUB_JOB":        "build",
+				"GITHUB_REF":        "refs/pull/123/merge",
+				"GITHUB_REF_NAME":   "123/merge",
+				"GITHUB_REF_TYPE":   "branch",
+				"GITHUB_REPOSITORY": "joshdk/hub-comment",
+				"GITHUB_RUN_ID":     "987654",
+				"GITHUB_RUN_NUMBER": "42",
+				"GITHUB_SERVER_URL": "https://github.com",
+				"GITHUB_SHA":        "c0ffee",
+				"GITHUB_WORKFLOW":   "CI",
+			},
+			name:      "GitHub Actions",
+			reference: "https://github.com/joshdk/hub-comment/pull/123",
+			build: map[string]string{
+				"CI":       "true",
+				"Index":    "0",
+				"Job":      "build",
+				"Nodes":    "1",
+				"Number":   "42",
+				"Owner":    "joshdk",
+				"Repo":     "hub-comment",
+				"Stage":    "CI",
+				"URL":      "https://github.com/joshdk/hub-comment/actions/runs/987654",
+				"User":     "joshdk",
+				"Workflow": "987654",
+			},
+			git: map[string]string{
+				"Branch": "feature",
+				"PR":     "https://github.com/joshdk/hub-comment/pull/123",
+				"SHA":    "c0ffee",
+				"Tag":    "",
+			},
+		},
+		{
+			title: "github actions tag",
+			env: map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_HEAD_REF":   "",
+				"GITHUB_REF":        "refs/tags/v1.0.0",
+				"GITHUB_REF_NAME":   "v1.0.0",
+				"GITHUB_REF_TYPE":   "tag",
+				"GITHUB_REPOSITORY": "joshdk/hub-comment",
+				"GITHUB_SHA":        "c0ffee",
+			},
+			name: "GitHub Actions",
+			git: map[string]string{
+				"Branch": "",
+				"PR":     "",
+				"SHA":    "c0ffee",
+				"Tag":    "v1.0.0",
+			},
+		},
+		{
+			title: "travis ci",
+			env: map[string]string{
+				"CI":                         "true",
+				"TRAVIS":                     "true",
+				"TRAVIS_BRANCH":              "master",
+				"TRAVIS_BUILD_ID":            "555",
+				"TRAVIS_BUILD_NUMBER":        "42",
+				"TRAVIS_BUILD_WEB_URL":       "https://travis-ci.com/joshdk/hub-comment/builds/555",
+				"TRAVIS_COMMIT":              "deadbeef",
+				"TRAVIS_JOB_NAME":            "build",
+				"TRAVIS_PULL_REQUEST":        "123",
+				"TRAVIS_PULL_REQUEST_BRANCH": "feature",
+				"TRAVIS_PULL_REQUEST_SHA":    "c0ffee",
+				"TRAVIS_REPO_SLUG":           "joshdk/hub-comment",
+			},
+			name:      "Travis CI",
+			reference: "https://github.com/joshdk/hub-comment/pull/123",
+			build: map[string]string{
+				"CI":       "true",
+				"Index":    "0",
+				"Job":      "build",
+				"Nodes":    "1",
+				"Number":   "42",
+				"Owner":    "joshdk",
+				"Repo":     "hub-comment",
+				"Stage":    "",
+				"URL":      "https://travis-ci.com/joshdk/hub-comment/builds/555",
+				"User":     "",
+				"Workflow": "555",
+			},
+			git: map[string]string{
+				"Branch": "feature",
+				"PR":     "https://github.com/joshdk/hub-comment/pull/123",
+				"SHA":    "c0ffee",
+				"Tag":    "",
+			},
+		},
+		{
+			title: "travis ci push",
+			env: map[string]string{
+				"TRAVIS":                     "true",
+				"TRAVIS_BRANCH":              "master",
+				"TRAVIS_COMMIT":              "deadbeef",
+				"TRAVIS_PULL_REQUEST":        "false",
+				"TRAVIS_PULL_REQUEST_BRANCH": "",
+				"TRAVIS_PULL_REQUEST_SHA":    "",
+				"TRAVIS_REPO_SLUG":           "joshdk/hub-comment",
+			},
+			name: "Travis CI",
+			git: map[string]string{
+				"Branch": "master",
+				"PR":     "",
+				"SHA":    "deadbeef",
+				"Tag":    "",
+			},
+		},
+		{
+			title: "jenkins",
+			env: map[string]string{
+				"BRANCH_NAME":   "PR-123",
+				"BUILD_NUMBER":  "42",
+				"BUILD_TAG":     "jenkins-hub-comment-PR-123-42",
+				"BUILD_URL":     "https://jenkins.example.com/job/hub-comment/job/PR-123/42/",
+				"CHANGE_AUTHOR": "joshdk",
+				"CHANGE_BRANCH": "feature",
+				"CHANGE_ID":     "123",
+				"CHANGE_URL":    "https://github.com/joshdk/hub-comment/pull/123",
+				"GIT_COMMIT":    "c0ffee",
+				"GIT_URL":       "https://github.com/joshdk/hub-comment.git",
+				"JENKINS_URL":   "https://jenkins.example.com/",
+				"JOB_NAME":      "hub-comment/PR-123",
+				"STAGE_NAME":    "Test",
+			},
+			name:      "Jenkins",
+			reference: "https://github.com/joshdk/hub-comment/pull/123",
+			build: map[string]string{
+				"CI":       "true",
+				"Index":    "0",
+				"Job":      "hub-comment/PR-123",
+				"Nodes":    "1",
+				"Number":   "42",
+				"Owner":    "joshdk",
+				"Repo":     "hub-comment",
+				"Stage":    "Test",
+				"URL":      "https://jenkins.example.com/job/hub-comment/job/PR-123/42/",
+				"User":     "joshdk",
+				"Workflow": "jenkins-hub-comment-PR-123-42",
+			},
+			git: map[string]string{
+				"Branch": "feature",
+				"PR":     "https://github.com/joshdk/hub-comment/pull/123",
+				"SHA":    "c0ffee",
+				"Tag":    "",
+			},
+		},
+		{
+			title: "buildkite",
+			env: map[string]string{
+				"BUILDKITE":                    "true",
+				"BUILDKITE_BRANCH":             "feature",
+				"BUILDKITE_BUILD_CREATOR":      "Josh Komoroske",
+				"BUILDKITE_BUILD_ID":           "f00-ba7",
+				"BUILDKITE_BUILD_NUMBER":       "42",
+				"BUILDKITE_BUILD_URL":          "https://buildkite.com/joshdk/hub-comment/builds/42",
+				"BUILDKITE_COMMIT":             "c0ffee",
+				"BUILDKITE_LABEL":              ":go: test",
+				"BUILDKITE_PARALLEL_JOB":       "1",
+				"BUILDKITE_PARALLEL_JOB_COUNT": "3",
+				"BUILDKITE_PULL_REQUEST":       "123",
+				"BUILDKITE_REPO":               "git@github.com:joshdk/hub-comment.git",
+				"BUILDKITE_STEP_KEY":           "test",
+				"CI":                           "true",
+			},
+			name:      "Buildkite",
+			reference: "https://github.com/joshdk/hub-comment/pull/123",
+			build: map[string]string{
+				"CI":       "true",
+				"Index":    "1",
+				"Job":      ":go: test",
+				"Nodes":    "3",
+				"Number":   "42",
+				"Owner":    "joshdk",
+				"Repo":     "hub-comment",
+				"Stage":    "test",
+				"URL":      "https://buildkite.com/joshdk/hub-comment/builds/42",
+				"User":     "Josh Komoroske",
+				"Workflow": "f00-ba7",
+			},
+			git: map[string]string{
+				"Branch": "feature",
+				"PR":     "https://github.com/joshdk/hub-comment/pull/123",
+				"SHA":    "c0ffee",
+				"Tag":    "",
+			},
+		},
+		{
+			title: "buildkite push",
+			env: map[string]string{
+				"BUILDKITE":              "true",
+				"BUILDKITE_PULL_REQUEST": "false",
+				"BUILDKITE_REPO":         "https://github.com/joshdk/hub-comment.git",
+			},
+			name: "Buildkite",
+		},
+		{
+			title: "drone",
+			env: map[string]string{
+				"CI":                  "true",
+				"DRONE":               "true",
+				"DRONE_BRANCH":        "master",
+				"DRONE_BUILD_LINK":    "https://drone.example.com/joshdk/hub-comment/42",
+				"DRONE_BUILD_NUMBER":  "42",
+				"DRONE_COMMIT_AUTHOR": "joshdk",
+				"DRONE_COMMIT_SHA":    "c0ffee",
+				"DRONE_PULL_REQUEST":  "123",
+				"DRONE_REPO":          "joshdk/hub-comment",
+				"DRONE_REPO_LINK":     "https://github.com/joshdk/hub-comment",
+				"DRONE_REPO_NAME":     "hub-comment",
+				"DRONE_REPO_OWNER":    "joshdk",
+				"DRONE_SOURCE_BRANCH": "feature",
+				"DRONE_STAGE_NAME":    "default",
+				"DRONE_STEP_NAME":     "test",
+			},
+			name:      "Drone",
+			reference: "https://github.com/joshdk/hub-comment/pull/123",
+			build: map[string]string{
+				"CI":       "true",
+				"Index":    "0",
+				"Job":      "test",
+				"Nodes":    "1",
+				"Number":   "42",
+				"Owner":    "joshdk",
+				"Repo":     "hub-comment",
+				"Stage":    "default",
+				"URL":      "https://drone.example.com/joshdk/hub-comment/42",
+				"User":     "joshdk",
+				"Workflow": "",
+			},
+			git: map[string]string{
+				"Branch": "feature",
+				"PR":     "https://github.com/joshdk/hub-comment/pull/123",
+				"SHA":    "c0ffee",
+				"Tag":    "",
+			},
+		},
+		{
+			title: "gitlab ci",
+			env: map[string]string{
+				"CI":                           "true",
+				"CI_COMMIT_SHA":                "deadbeef",
+				"CI_EXTERNAL_PULL_REQUEST_IID": "123",
+				"CI_EXTERNAL_PULL_REQUEST_SOURCE_BRANCH_NAME": "feature",
+				"CI_EXTERNAL_PULL_REQUEST_SOURCE_BRANCH_SHA":  "c0ffee",
+				"CI_EXTERNAL_PULL_REQUEST_TARGET_REPOSITORY":  "joshdk/hub-comment",
+				"CI_JOB_NAME":       "test",
+				"CI_JOB_STAGE":      "verify",
+				"CI_NODE_INDEX":     "2",
+				"CI_NODE_TOTAL":     "2",
+				"CI_PIPELINE_ID":    "7777",
+				"CI_PIPELINE_IID":   "42",
+				"CI_PIPELINE_URL":   "https://gitlab.com/joshdk/hub-comment/-/pipelines/7777",
+				"CI_PROJECT_PATH":   "mirrors/hub-comment",
+				"GITLAB_CI":         "true",
+				"GITLAB_USER_LOGIN": "joshdk",
+			},
+			name:      "GitLab CI",
+			reference: "https://github.com/joshdk/hub-comment/pull/123",
+			build: map[string]string{
+				"CI":       "true",
+				"Index":    "1",
+				"Job":      "test",
+				"Nodes":    "2",
+				"Number":   "42",
+				"Owner":    "joshdk",
+				"Repo":     "hub-comment",
+				"Stage":    "verify",
+				"URL":      "https://gitlab.com/joshdk/hub-comment/-/pipelines/7777",
+				"User":     "joshdk",
+				"Workflow": "7777",
+			},
+			git: map[string]string{
+				"Branch": "feature",
+				"PR":     "https://github.com/joshdk/hub-comment/pull/123",
+				"SHA":    "c0ffee",
+				"Tag":    "",
+			},
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			provider := DetectProvider(test.env)
+			reference, found := provider.Reference(test.env)
+
+			assert.Equal(t, test.name, provider.Name())
+			assert.Equal(t, test.reference, reference)
+			assert.Equal(t, test.reference != "", found)
+			if test.build != nil {
+				assert.Equal(t, test.build, provider.Build(test.env))
+			}
+			if test.git != nil {
+				assert.Equal(t, test.git, provider.Git(test.env))
+			}
+		})
+	}
+}
@@ -6,37 +6,132 @@ package hub
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/google/go-github/github"
 )
 
 var (
-	// rePullLink is a regex intended to match strings that look like
-	// "https://github.com/joshdk/hub-comment/pull/123".
-	rePullLink = regexp.MustCompile(`https://.*/([a-zA-A0-9_.-]+)/([a-zA-A0-9_.-]+)/pull/([1-9]\d*)`)
+	// reName is a regex intended to match valid owner and repo names, like
+	// "joshdk" or "hub-comment".
+	reName = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+	// reShortReference is a regex intended to match short references that
+	// look like "joshdk/hub-comment#123".
+	reShortReference = regexp.MustCompile(`^([^/#]*)/([^/#]*)#(.*)$`)
 )
 
-// splitPullRequestReference splits a pull request reference string into the
-// owner, repo, and number of the PR.
+// Reference identifies a single pull request, or issue, on a GitHub server.
+type Reference struct {
+	// Host is the host of the GitHub server, like "github.com". Empty if the
+	// reference did not name a server.
+	Host string
+
+	// Owner is the user or organization that owns the repository.
+	Owner string
+
+	// Repo is the name of the repository.
+	Repo string
+
+	// Number is the pull request or issue number.
+	Number int
+}
+
+// ParseReference parses a pull request reference string into the host, owner,
+// repo, and number of the PR. An error describing the problem is returned if
+// the reference is malformed.
 //
 // References should look like "https://github.com/joshdk/hub-comment/pull/123"
-// or "joshdk/hub-comment#123".
-func SplitPullRequestReference(reference string) (string, string, int, bool) {
-	res := rePullLink.FindStringSubmatch(reference)
-	if res == nil || len(res) != 4 {
-		return "", "", 0, false
+// or "joshdk/hub-comment#123". Links to the files or commits tab of a pull
+// request, as well as links to issues, are also accepted.
+func ParseReference(reference string) (Reference, error) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		return Reference{}, fmt.Errorf("reference is empty")
+	}
+
+	// Short references never contain a scheme, and always contain a "#".
+	// They also do not name a server.
+	if !strings.Contains(reference, "://") {
+		res := reShortReference.FindStringSubmatch(reference)
+		if res == nil {
+			return Reference{}, fmt.Errorf("reference %q is neither a link nor of the form owner/repo#number", reference)
+		}
+		return newReference(reference, "", res[1], res[2], res[3])
+	}
+
+	link, err := url.Parse(reference)
+	if err != nil {
+		return Reference{}, fmt.Errorf("reference %q is not a valid link: %v", reference, err)
+	}
+
+	if link.Scheme != "https" && link.Scheme != "http" {
+		return Reference{}, fmt.Errorf("reference %q has unsupported scheme %q", reference, link.Scheme)
 	}
 
-	var (
-		owner     = res[1]
-		repo      = res[2]
-		number, _ = strconv.Atoi(res[3])
-	)
+	if link.Host == "" {
+		return Reference{}, fmt.Errorf("reference %q has no host", reference)
+	}
+
+	// Split the path into pieces, ignoring any trailing slashes. The query
+	// string and fragment have already been discarded by url.Parse.
+	pieces := strings.Split(strings.Trim(link.Path, "/"), "/")
+	if len(pieces) < 4 {
+		return Reference{}, fmt.Errorf("reference %q is not a link to a pull request or issue", reference)
+	}
+
+	// Links to the files or commits tab of a pull request are accepted, but
+	// anything else past the number is not.
+	switch pieces[2] {
+	case "pull":
+		if len(pieces) > 4 && !(len(pieces) == 5 && (pieces[4] == "files" || pieces[4] == "commits")) {
+			return Reference{}, fmt.Errorf("reference %q has unsupported path %q", reference, link.Path)
+		}
+	case "issues":
+		if len(pieces) > 4 {
+			return Reference{}, fmt.Errorf("reference %q has unsupported path %q", reference, link.Path)
+		}
+	default:
+		return Reference{}, fmt.Errorf("reference %q is not a link to a pull request or issue", reference)
+	}
+
+	return newReference(reference, link.Host, pieces[0], pieces[1], pieces[3])
+}
+
+// newReference validates the individual pieces of a reference, and combines
+// them into a Reference.
+func newReference(reference string, host string, owner string, repo string, number string) (Reference, error) {
+	repo = strings.TrimSuffix(repo, ".git")
+
+	switch {
+	case owner == "":
+		return Reference{}, fmt.Errorf("reference %q has no owner", reference)
+	case !reName.MatchString(owner):
+		return Reference{}, fmt.Errorf("reference %q has invalid owner %q", reference, owner)
+	case repo == "":
+		return Reference{}, fmt.Errorf("reference %q has no repo", reference)
+	case !reName.MatchString(repo):
+		return Reference{}, fmt.Errorf("reference %q has invalid repo %q", reference, repo)
+	case number == "":
+		return Reference{}, fmt.Errorf("reference %q has no number", reference)
+	}
+
+	n, err := strconv.Atoi(number)
+	if err != nil || n <= 0 || strings.HasPrefix(number, "0") || strings.HasPrefix(number, "+") {
+		return Reference{}, fmt.Errorf("reference %q has invalid number %q", reference, number)
+	}
 
-	return owner, repo, number, true
+	return Reference{
+		Host:   host,
+		Owner:  owner,
+		Repo:   repo,
+		Number: n,
+	}, nil
 }
 
 // GetIssue fetches information about the current pull request.
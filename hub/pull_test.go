@@ -0,0 +1,160 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		title     string
+		reference string
+		expected  Reference
+		error     string
+	}{
+		{
+			title: "empty string",
+			error: `reference is empty`,
+		},
+		{
+			title:     "pull link",
+			reference: "https://github.com/joshdk/hub-comment/pull/123",
+			expected:  Reference{"github.com", "joshdk", "hub-comment", 123},
+		},
+		{
+			title:     "pull link with trailing slash",
+			reference: "https://github.com/joshdk/hub-comment/pull/123/",
+			expected:  Reference{"github.com", "joshdk", "hub-comment", 123},
+		},
+		{
+			title:     "pull link with query string and fragment",
+			reference: "https://github.com/joshdk/hub-comment/pull/123?w=1#issuecomment-421483151",
+			expected:  Reference{"github.com", "joshdk", "hub-comment", 123},
+		},
+		{
+			title:     "pull files link",
+			reference: "https://github.com/joshdk/hub-comment/pull/123/files",
+			expected:  Reference{"github.com", "joshdk", "hub-comment", 123},
+		},
+		{
+			title:     "pull commits link",
+			reference: "https://github.com/joshdk/hub-comment/pull/123/commits/",
+			expected:  Reference{"github.com", "joshdk", "hub-comment", 123},
+		},
+		{
+			title:     "pull link with uppercase owner",
+			reference: "https://github.com/QuantumQ/Hub.Comment/pull/123",
+			expected:  Reference{"github.com", "QuantumQ", "Hub.Comment", 123},
+		},
+		{
+			title:     "pull link with git suffix",
+			reference: "https://github.com/joshdk/hub-comment.git/pull/123",
+			expected:  Reference{"github.com", "joshdk", "hub-comment", 123},
+		},
+		{
+			title:     "enterprise pull link",
+			reference: "https://github.example.com:8443/joshdk/hub-comment/pull/123",
+			expected:  Reference{"github.example.com:8443", "joshdk", "hub-comment", 123},
+		},
+		{
+			title:     "issue link",
+			reference: "https://github.com/joshdk/hub-comment/issues/123",
+			expected:  Reference{"github.com", "joshdk", "hub-comment", 123},
+		},
+		{
+			title:     "short reference",
+			reference: "joshdk/hub-comment#123",
+			expected:  Reference{"", "joshdk", "hub-comment", 123},
+		},
+		{
+			title:     "short reference with git suffix",
+			reference: "joshdk/hub-comment.git#123",
+			expected:  Reference{"", "joshdk", "hub-comment", 123},
+		},
+		{
+			title:     "unsupported scheme",
+			reference: "ftp://github.com/joshdk/hub-comment/pull/123",
+			error:     `reference "ftp://github.com/joshdk/hub-comment/pull/123" has unsupported scheme "ftp"`,
+		},
+		{
+			title:     "missing host",
+			reference: "https:///joshdk/hub-comment/pull/123",
+			error:     `reference "https:///joshdk/hub-comment/pull/123" has no host`,
+		},
+		{
+			title:     "repository link",
+			reference: "https://github.com/joshdk/hub-comment",
+			error:     `reference "https://github.com/joshdk/hub-comment" is not a link to a pull request or issue`,
+		},
+		{
+			title:     "blob link",
+			reference: "https://github.com/joshdk/hub-comment/blob/master/main.go",
+			error:     `reference "https://github.com/joshdk/hub-comment/blob/master/main.go" is not a link to a pull request or issue`,
+		},
+		{
+			title:     "unsupported pull tab",
+			reference: "https://github.com/joshdk/hub-comment/pull/123/checks",
+			error:     `reference "https://github.com/joshdk/hub-comment/pull/123/checks" has unsupported path "/joshdk/hub-comment/pull/123/checks"`,
+		},
+		{
+			title:     "zero number",
+			reference: "https://github.com/joshdk/hub-comment/pull/0",
+			error:     `reference "https://github.com/joshdk/hub-comment/pull/0" has invalid number "0"`,
+		},
+		{
+			title:     "leading zero number",
+			reference: "joshdk/hub-comment#0123",
+			error:     `reference "joshdk/hub-comment#0123" has invalid number "0123"`,
+		},
+		{
+			title:     "non-numeric number",
+			reference: "joshdk/hub-comment#abc",
+			error:     `reference "joshdk/hub-comment#abc" has invalid number "abc"`,
+		},
+		{
+			title:     "missing number",
+			reference: "joshdk/hub-comment#",
+			error:     `reference "joshdk/hub-comment#" has no number`,
+		},
+		{
+			title:     "missing owner",
+			reference: "/hub-comment#123",
+			error:     `reference "/hub-comment#123" has no owner`,
+		},
+		{
+			title:     "missing repo",
+			reference: "joshdk/#123",
+			error:     `reference "joshdk/#123" has no repo`,
+		},
+		{
+			title:     "invalid owner",
+			reference: "josh dk/hub-comment#123",
+			error:     `reference "josh dk/hub-comment#123" has invalid owner "josh dk"`,
+		},
+		{
+			title:     "missing hash",
+			reference: "joshdk/hub-comment",
+			error:     `reference "joshdk/hub-comment" is neither a link nor of the form owner/repo#number`,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			actual, err := ParseReference(test.reference)
+
+			if test.error != "" {
+				assert.EqualError(t, err, test.error)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
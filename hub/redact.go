@@ -0,0 +1,141 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// redactedValue is the value used in place of any redacted environment
+	// variable.
+	redactedValue = "[redacted]"
+
+	// minSecretLength is the length that a secret value must reach before it
+	// is searched for inside of rendered comments. Shorter values, like "1" or
+	// "true", are far too likely to appear by coincidence.
+	minSecretLength = 8
+)
+
+var (
+	// DefaultRedactPatterns is the list of patterns matching the names of
+	// environment variables that are always redacted.
+	DefaultRedactPatterns = []string{
+		"*_TOKEN",
+		"*_KEY",
+		"*SECRET*",
+		"*PASSWORD*",
+		"*PASSWD*",
+		"*CREDENTIAL*",
+	}
+
+	// knownSecrets is a list of the names of environment variables that are
+	// known to hold secrets, but which are not matched by any of the default
+	// patterns.
+	knownSecrets = []string{
+		"AWS_ACCESS_KEY_ID",
+		"NPM_AUTH",
+		"NPM_CONFIG__AUTH",
+	}
+
+	// reTokens is a list of regexes intended to match strings shaped like
+	// well known kinds of credentials.
+	reTokens = []*regexp.Regexp{
+		// GitHub personal access, OAuth, user-to-server, server-to-server,
+		// and refresh tokens.
+		regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+		// GitHub fine-grained personal access tokens.
+		regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,}\b`),
+		// AWS access key IDs.
+		regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+		// Slack tokens.
+		regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),
+		// Private keys.
+		regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	}
+)
+
+// Redactor removes secrets from the environment before it is exposed to
+// templates, and verifies that rendered comments do not contain any secrets.
+type Redactor struct {
+	// patterns is the list of patterns matching the names of environment
+	// variables to redact.
+	patterns []string
+
+	// secrets is the list of values that have been redacted.
+	secrets []string
+}
+
+// NewRedactor builds a Redactor that redacts the default patterns, along with
+// the given list of additional patterns. Patterns use the syntax of
+// path.Match, and are matched case-insensitively.
+func NewRedactor(patterns []string) (*Redactor, error) {
+	all := append(append([]string{}, DefaultRedactPatterns...), patterns...)
+	for index, pattern := range all {
+		all[index] = strings.ToUpper(pattern)
+		if _, err := path.Match(all[index], ""); err != nil {
+			return nil, fmt.Errorf("malformed redact pattern %q", pattern)
+		}
+	}
+
+	return &Redactor{patterns: all}, nil
+}
+
+// matches returns true if the named environment variable should be redacted.
+func (r *Redactor) matches(name string) bool {
+	name = strings.ToUpper(name)
+	for _, known := range knownSecrets {
+		if name == known {
+			return true
+		}
+	}
+	for _, pattern := range r.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of the given environment, where the value of every
+// variable that might hold a secret is masked. The original values are
+// remembered so that they can be searched for later by Check.
+func (r *Redactor) Redact(env map[string]string) map[string]string {
+	redacted := make(map[string]string, len(env))
+	for name, value := range env {
+		if !r.matches(name) || value == "" {
+			redacted[name] = value
+			continue
+		}
+
+		redacted[name] = redactedValue
+		if len(value) >= minSecretLength {
+			r.secrets = append(r.secrets, value)
+		}
+	}
+	return redacted
+}
+
+// Check returns an error if the given comment contains either the value of any
+// redacted environment variable, or any string shaped like a well known kind of
+// credential. The secret itself is never included in the error.
+func (r *Redactor) Check(comment string) error {
+	for _, secret := range r.secrets {
+		if strings.Contains(comment, secret) {
+			return fmt.Errorf("rendered comment contains the value of a redacted environment variable")
+		}
+	}
+
+	for _, re := range reTokens {
+		if re.MatchString(comment) {
+			return fmt.Errorf("rendered comment contains a string that looks like a credential")
+		}
+	}
+
+	return nil
+}
@@ -46,3 +46,64 @@ func Report(comment, url, user, login, owner, repo string, number int, updated b
 	fmt.Println("To view comment visit:")
 	fmt.Printf("→ %s\n", url)
 }
+
+// ReportDeleted display a textual report about the pull request, and the
+// existing comment that was just deleted, if there was one.
+func ReportDeleted(url, user, login, owner, repo string, number int, found bool, dryRun bool) {
+	var prefix = "Deleting existing comment by"
+
+	switch {
+	case !found:
+		fmt.Printf(
+			"No existing comment by %s (%s) on %s/%s#%d to delete.\n",
+			user, login,
+			owner, repo, number,
+		)
+		return
+	case dryRun:
+		prefix = "Would have deleted existing comment by"
+	}
+
+	fmt.Printf(
+		"%s %s (%s) on %s/%s#%d:\n",
+		prefix,
+		user, login,
+		owner, repo, number,
+	)
+
+	fmt.Println()
+	fmt.Printf("→ %s\n", url)
+}
+
+// ReportHidden display a textual report about the outdated comments that were
+// just hidden, if there were any.
+func ReportHidden(urls []string, dryRun bool) {
+	if len(urls) == 0 {
+		return
+	}
+
+	prefix := "Hiding outdated comments:"
+	if dryRun {
+		prefix = "Would have hidden outdated comments:"
+	}
+
+	fmt.Println()
+	fmt.Println(prefix)
+	for _, url := range urls {
+		fmt.Printf("→ %s\n", url)
+	}
+}
+
+// ReportUnchanged display a textual report about the pull request, and the
+// existing comment that was left as-is because its content was unchanged.
+func ReportUnchanged(url, user, login, owner, repo string, number int) {
+	fmt.Printf(
+		"Existing comment by %s (%s) on %s/%s#%d is unchanged, skipped.\n",
+		user, login,
+		owner, repo, number,
+	)
+
+	fmt.Println()
+	fmt.Println("To view comment visit:")
+	fmt.Printf("→ %s\n", url)
+}
@@ -0,0 +1,127 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StdinFile is the file name that causes a template or report to be read from
+// stdin.
+const StdinFile = "-"
+
+// LoadTemplates will either return the contents of template verbatim, return
+// the contents read from every file matched by templateFiles, or return the
+// named built-in template. If none are given, the template named after
+// typeName is read from templateDir instead. If that does not exist either,
+// the built-in template named by fallback is used, if any. Each template is
+// named after the base name of its file, for use when reporting errors and
+// when referencing one template from another. A template file named "-" is
+// read from stdin.
+func LoadTemplates(template string, templateFiles []string, builtin string, templateDir string, typeName string, fallback string, stdin io.Reader) ([]TemplateFile, error) {
+	given := 0
+	for _, ok := range []bool{template != "", len(templateFiles) != 0, builtin != ""} {
+		if ok {
+			given++
+		}
+	}
+
+	switch {
+	case given > 1:
+		return nil, fmt.Errorf("only one of a template, a template file, or a built-in template may be given")
+	case template != "":
+		return []TemplateFile{{Name: "comment", Body: []byte(template)}}, nil
+	case builtin != "":
+		return builtinTemplate(builtin)
+	case len(templateFiles) == 0:
+		templates, err := bundleTemplate(templateDir, typeName)
+		if err != nil && fallback != "" {
+			return builtinTemplate(fallback)
+		}
+		return templates, err
+	}
+
+	var templates []TemplateFile
+	for _, pattern := range templateFiles {
+		if pattern == StdinFile {
+			body, err := ioutil.ReadAll(stdin)
+			if err != nil {
+				return nil, err
+			}
+			templates = append(templates, TemplateFile{Name: "stdin", Body: body})
+			continue
+		}
+
+		matches, err := ExpandGlob(pattern, "template")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			body, err := ioutil.ReadFile(match)
+			if err != nil {
+				return nil, err
+			}
+			templates = append(templates, TemplateFile{
+				Name: filepath.Base(match),
+				Path: match,
+				Body: body,
+			})
+		}
+	}
+
+	return templates, nil
+}
+
+// builtinTemplate returns the named built-in template.
+func builtinTemplate(name string) ([]TemplateFile, error) {
+	template, found := BuiltinTemplate(name)
+	if !found {
+		return nil, fmt.Errorf("unknown built-in template %q, must be one of %s", name, strings.Join(BuiltinTemplateNames(), ", "))
+	}
+	return []TemplateFile{template}, nil
+}
+
+// bundleTemplate returns the template for the given comment type, read from a
+// file named like <type>.md inside of templateDir. Types that would name a
+// file outside of templateDir are rejected.
+func bundleTemplate(templateDir string, typeName string) ([]TemplateFile, error) {
+	if strings.ContainsAny(typeName, `/\`) || typeName == "." || typeName == ".." {
+		return nil, fmt.Errorf("a template or a template file must be given")
+	}
+
+	path := filepath.Join(templateDir, typeName+".md")
+	body, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return nil, fmt.Errorf("a template or a template file must be given, or %s must exist", path)
+	case err != nil:
+		return nil, err
+	}
+
+	return []TemplateFile{{Name: filepath.Base(path), Path: path, Body: body}}, nil
+}
+
+// ExpandGlob returns every file matching the given glob pattern. Paths that are
+// not globs are returned as-is, so that a missing file is reported as such by
+// the caller. The kind of file is used when reporting errors.
+func ExpandGlob(pattern string, kind string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("malformed %s file pattern %q", kind, pattern)
+	case len(matches) > 0:
+		return matches, nil
+	case strings.ContainsAny(pattern, "*?["):
+		return nil, fmt.Errorf("no %s files match %q", kind, pattern)
+	default:
+		return []string{pattern}, nil
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hub-comment")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"a.md":              "Template A",
+		"b.md":              "Template B",
+		"x.md":              "Outside of the template directory",
+		"templates/lint.md": "Lint bundle",
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(body), 0644))
+	}
+
+	var (
+		templateDir = filepath.Join(dir, "templates")
+		missing     = filepath.Join(templateDir, "default.md")
+	)
+
+	tests := []struct {
+		title     string
+		template  string
+		files     []string
+		builtin   string
+		typeName  string
+		fallback  string
+		stdin     string
+		names     []string
+		bodies    []string
+		error     string
+		errorHint string
+	}{
+		{
+			title:    "template",
+			template: "Hello {{.Pull.Author}}!",
+			typeName: "lint",
+			names:    []string{"comment"},
+			bodies:   []string{"Hello {{.Pull.Author}}!"},
+		},
+		{
+			title:    "template and template file",
+			template: "Hello",
+			files:    []string{filepath.Join(dir, "a.md")},
+			error:    "only one of a template, a template file, or a built-in template may be given",
+		},
+		{
+			title:    "template file and built-in template",
+			files:    []string{filepath.Join(dir, "a.md")},
+			builtin:  "tests",
+			typeName: "default",
+			error:    "only one of a template, a template file, or a built-in template may be given",
+		},
+		{
+			title:    "template files",
+			files:    []string{filepath.Join(dir, "b.md"), filepath.Join(dir, "a.md")},
+			typeName: "lint",
+			names:    []string{"b.md", "a.md"},
+			bodies:   []string{"Template B", "Template A"},
+		},
+		{
+			title:    "template file glob",
+			files:    []string{filepath.Join(dir, "*.md")},
+			typeName: "default",
+			names:    []string{"a.md", "b.md", "x.md"},
+		},
+		{
+			title: "template file glob without matches",
+			files: []string{filepath.Join(dir, "*.txt")},
+			error: fmt.Sprintf("no template files match %q", filepath.Join(dir, "*.txt")),
+		},
+		{
+			title:     "missing template file",
+			files:     []string{filepath.Join(dir, "missing.md")},
+			errorHint: "no such file or directory",
+		},
+		{
+			title:    "stdin",
+			files:    []string{"-"},
+			typeName: "default",
+			stdin:    "Hello from stdin",
+			names:    []string{"stdin"},
+			bodies:   []string{"Hello from stdin"},
+		},
+		{
+			title:    "stdin and template file",
+			files:    []string{"-", filepath.Join(dir, "a.md")},
+			typeName: "default",
+			stdin:    "Hello from stdin",
+			names:    []string{"stdin", "a.md"},
+			bodies:   []string{"Hello from stdin", "Template A"},
+		},
+		{
+			title:    "built-in template",
+			builtin:  "tests",
+			typeName: "lint",
+			names:    []string{"tests"},
+		},
+		{
+			title:   "unknown built-in template",
+			builtin: "nope",
+			error:   `unknown built-in template "nope", must be one of bench, coverage, gotest, lint, tests`,
+		},
+		{
+			title:    "bundle template",
+			typeName: "lint",
+			fallback: "lint",
+			names:    []string{"lint.md"},
+			bodies:   []string{"Lint bundle"},
+		},
+		{
+			title:    "missing bundle template",
+			typeName: "default",
+			error:    fmt.Sprintf("a template or a template file must be given, or %s must exist", missing),
+		},
+		{
+			title:    "missing bundle template with fallback",
+			typeName: "default",
+			fallback: "tests",
+			names:    []string{"tests"},
+		},
+		{
+			title:    "missing bundle template with unknown fallback",
+			typeName: "default",
+			fallback: "nope",
+			error:    `unknown built-in template "nope", must be one of bench, coverage, gotest, lint, tests`,
+		},
+		{
+			title:    "type outside of template directory",
+			typeName: "../x",
+			error:    "a template or a template file must be given",
+		},
+		{
+			title:    "type with backslash",
+			typeName: `..\x`,
+			error:    "a template or a template file must be given",
+		},
+		{
+			title:    "type of parent directory",
+			typeName: "..",
+			error:    "a template or a template file must be given",
+		},
+		{
+			title:    "type of current directory",
+			typeName: ".",
+			error:    "a template or a template file must be given",
+		},
+		{
+			title:    "type outside of template directory with fallback",
+			typeName: "../x",
+			fallback: "lint",
+			names:    []string{"lint"},
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			templates, err := LoadTemplates(test.template, test.files, test.builtin, templateDir, test.typeName, test.fallback, strings.NewReader(test.stdin))
+			switch {
+			case test.error != "":
+				assert.EqualError(t, err, test.error)
+				return
+			case test.errorHint != "":
+				if assert.NotNil(t, err) {
+					assert.Contains(t, err.Error(), test.errorHint)
+				}
+				return
+			}
+			assert.Nil(t, err)
+
+			var names, bodies []string
+			for _, template := range templates {
+				names = append(names, template.Name)
+				bodies = append(bodies, string(template.Body))
+			}
+			assert.Equal(t, test.names, names)
+			if test.bodies != nil {
+				assert.Equal(t, test.bodies, bodies)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"time"
+)
+
+const (
+	// TestPassed is the status of a test that passed.
+	TestPassed = "passed"
+
+	// TestFailed is the status of a test that failed an assertion.
+	TestFailed = "failed"
+
+	// TestErrored is the status of a test that could not be run to
+	// completion, like one that panicked.
+	TestErrored = "errored"
+
+	// TestSkipped is the status of a test that was skipped.
+	TestSkipped = "skipped"
+)
+
+// TestReport represents the combined results of one or more test suites.
+type TestReport struct {
+	// Suites is the list of every test suite.
+	Suites []TestSuite
+
+	// Total is the number of tests that were run, or skipped.
+	Total int
+
+	// Passed is the number of tests that passed.
+	Passed int
+
+	// Failed is the number of tests that failed, or errored.
+	Failed int
+
+	// Skipped is the number of tests that were skipped.
+	Skipped int
+
+	// Duration is the combined time taken by every test suite.
+	Duration time.Duration
+}
+
+// TestSuite represents the results of a single group of tests, like a JUnit
+// test suite or a Go package.
+type TestSuite struct {
+	// Name is the name of the suite.
+	Name string
+
+	// Cases is the list of every test in the suite.
+	Cases []TestCase
+
+	// Duration is the time taken by the suite.
+	Duration time.Duration
+}
+
+// TestCase represents the result of a single test.
+type TestCase struct {
+	// Suite is the name of the suite containing this test.
+	Suite string
+
+	// Class is the name of the class containing this test, if known.
+	Class string
+
+	// Name is the name of the test.
+	Name string
+
+	// Status is one of TestPassed, TestFailed, TestErrored, or TestSkipped.
+	Status string
+
+	// Duration is the time taken by the test.
+	Duration time.Duration
+
+	// Message is a short, single line, description of why the test failed or
+	// was skipped.
+	Message string
+
+	// Output is the stack trace or captured output of a failed test.
+	Output string
+}
+
+// Failed returns true if the test failed or errored.
+func (c TestCase) Failed() bool {
+	return c.Status == TestFailed || c.Status == TestErrored
+}
+
+// count returns the number of tests in the suite with any of the given
+// statuses.
+func (s TestSuite) count(statuses ...string) int {
+	total := 0
+	for _, c := range s.Cases {
+		for _, status := range statuses {
+			if c.Status == status {
+				total++
+			}
+		}
+	}
+	return total
+}
+
+// Passed returns the number of tests in the suite that passed.
+func (s TestSuite) Passed() int {
+	return s.count(TestPassed)
+}
+
+// Failed returns the number of tests in the suite that failed or errored.
+func (s TestSuite) Failed() int {
+	return s.count(TestFailed, TestErrored)
+}
+
+// Skipped returns the number of tests in the suite that were skipped.
+func (s TestSuite) Skipped() int {
+	return s.count(TestSkipped)
+}
+
+// NewTestReport combines the given test suites into a single report.
+func NewTestReport(suites []TestSuite) *TestReport {
+	report := &TestReport{}
+	report.Add(suites...)
+	return report
+}
+
+// Add includes the given test suites in the report, and updates all counts.
+func (r *TestReport) Add(suites ...TestSuite) {
+	for _, suite := range suites {
+		r.Suites = append(r.Suites, suite)
+		r.Duration += suite.Duration
+
+		for _, c := range suite.Cases {
+			r.Total++
+			switch {
+			case c.Failed():
+				r.Failed++
+			case c.Status == TestSkipped:
+				r.Skipped++
+			default:
+				r.Passed++
+			}
+		}
+	}
+}
+
+// OK returns true if no test failed.
+func (r *TestReport) OK() bool {
+	return r.Failed == 0
+}
+
+// Failures returns every test that failed or errored, in order.
+func (r *TestReport) Failures() []TestCase {
+	var failures []TestCase
+	for _, suite := range r.Suites {
+		for _, c := range suite.Cases {
+			if c.Failed() {
+				failures = append(failures, c)
+			}
+		}
+	}
+	return failures
+}
@@ -0,0 +1,239 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/google/go-github/github"
+)
+
+// contextKeys returns a map of the names of every field on Context, to the set
+// of keys that the field is known to hold. Fields holding arbitrary keys, like
+// Env, map to nil.
+func contextKeys() map[string]map[string]bool {
+	var (
+		sample = NewContext(nil, map[string]string{}, circleCI{}, nil, &github.Issue{}, "")
+		keys   = func(m map[string]string) map[string]bool {
+			set := make(map[string]bool, len(m))
+			for key := range m {
+				set[key] = true
+			}
+			return set
+		}
+	)
+
+	return map[string]map[string]bool{
+		"Bench":    nil,
+		"Build":    keys(sample.Build),
+		"Coverage": nil,
+		"Data":     nil,
+		"Env":      nil,
+		"Event":    nil,
+		"Git":      keys(sample.Git),
+		"Labels":   nil,
+		"Lint":     nil,
+		"Meta":     keys(sample.Meta),
+		"Pull":     keys(sample.Pull),
+		"Tests":    nil,
+		"Vars":     nil,
+	}
+}
+
+// Validate statically checks the given template for references to unknown
+// Context fields, or to unknown keys in any of the fixed Context maps, such as
+// {{.Build.Nmuber}}. If an allowlist is given, references to environment
+// variables that are not allowed are also rejected. Errors name the template,
+// line, and column of the offending reference.
+//
+// Only references made relative to the top-level Context are checked, as the
+// value of dot inside of {{with}} and {{range}} blocks is not known. Templates
+// invoked with the top-level Context, like {{template "footer.md" .}}, are
+// checked as well.
+func Validate(tpl *template.Template, allowlist *Allowlist) error {
+	return validator{
+		tpl:       tpl,
+		keys:      contextKeys(),
+		allowlist: allowlist,
+		visited:   map[string]bool{tpl.Name(): true},
+	}.run()
+}
+
+// ValidateEnv is like Validate, but only rejects references to environment
+// variables that are not allowed by the given allowlist. References to
+// unknown fields and keys are ignored.
+func ValidateEnv(tpl *template.Template, allowlist *Allowlist) error {
+	return validator{
+		tpl:       tpl,
+		keys:      contextKeys(),
+		allowlist: allowlist,
+		envOnly:   true,
+		visited:   map[string]bool{tpl.Name(): true},
+	}.run()
+}
+
+// validator holds the state needed while walking a template parse tree.
+type validator struct {
+	tpl       *template.Template
+	keys      map[string]map[string]bool
+	allowlist *Allowlist
+
+	// envOnly is true if only references to environment variables are
+	// checked.
+	envOnly bool
+
+	// visited is the set of template names that have already been checked.
+	visited map[string]bool
+}
+
+// run checks the whole template.
+func (v validator) run() error {
+	if v.tpl.Tree == nil {
+		return nil
+	}
+	return v.walk(v.tpl.Tree.Root, true)
+}
+
+// walk recursively checks the given node, and all of its children. The rooted
+// parameter is true if dot refers to the top-level Context.
+func (v validator) walk(node parse.Node, rooted bool) error {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return nil
+		}
+		for _, child := range node.Nodes {
+			if err := v.walk(child, rooted); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return v.walk(node.Pipe, rooted)
+	case *parse.PipeNode:
+		if node == nil {
+			return nil
+		}
+		for _, cmd := range node.Cmds {
+			if err := v.walk(cmd, rooted); err != nil {
+				return err
+			}
+		}
+	case *parse.CommandNode:
+		// Looking up a literal key with {{index .Env "NAME"}} is checked the
+		// same as {{.Env.NAME}}.
+		if ident, ok := indexEnv(node, rooted); ok {
+			if err := v.check(node.Args[2], ident); err != nil {
+				return err
+			}
+		}
+		for _, arg := range node.Args {
+			if err := v.walk(arg, rooted); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return v.walkBranch(&node.BranchNode, rooted, rooted)
+	case *parse.WithNode:
+		return v.walkBranch(&node.BranchNode, rooted, false)
+	case *parse.RangeNode:
+		return v.walkBranch(&node.BranchNode, rooted, false)
+	case *parse.TemplateNode:
+		if err := v.walk(node.Pipe, rooted); err != nil {
+			return err
+		}
+		if rooted && isDot(node.Pipe) && !v.visited[node.Name] {
+			v.visited[node.Name] = true
+			if called := v.tpl.Lookup(node.Name); called != nil && called.Tree != nil {
+				return v.walk(called.Tree.Root, true)
+			}
+		}
+	case *parse.FieldNode:
+		if rooted {
+			return v.check(node, node.Ident)
+		}
+	case *parse.VariableNode:
+		// Only the "$" variable is known to always refer to the top-level
+		// Context.
+		if len(node.Ident) > 1 && node.Ident[0] == "$" {
+			return v.check(node, node.Ident[1:])
+		}
+	}
+	return nil
+}
+
+// walkBranch checks the pipeline and both lists of an if, with, or range node.
+// The inner parameter is true if dot refers to the top-level Context inside of
+// the main list.
+func (v validator) walkBranch(node *parse.BranchNode, rooted bool, inner bool) error {
+	if err := v.walk(node.Pipe, rooted); err != nil {
+		return err
+	}
+	if err := v.walk(node.List, inner); err != nil {
+		return err
+	}
+	return v.walk(node.ElseList, rooted)
+}
+
+// check verifies that the given chain of identifiers, relative to the top-level
+// Context, names a known field and key.
+func (v validator) check(node parse.Node, ident []string) error {
+	location, _ := v.tpl.ErrorContext(node)
+
+	keys, found := v.keys[ident[0]]
+	switch {
+	case !found && !v.envOnly:
+		return fmt.Errorf("template: %s: unknown field .%s", location, ident[0])
+	case len(ident) < 2:
+		return nil
+	case ident[0] == "Env" && v.allowlist != nil && !v.allowlist.Allowed(ident[1]):
+		return fmt.Errorf("template: %s: environment variable %q is not allowed by -env-allow", location, ident[1])
+	case keys != nil && !keys[ident[1]] && !v.envOnly:
+		return fmt.Errorf("template: %s: unknown key %q in .%s", location, ident[1], ident[0])
+	}
+	return nil
+}
+
+// indexEnv returns the identifiers equivalent to the given command, if it looks
+// up a literal key in the environment, like {{index .Env "NAME"}} or
+// {{index $.Env "NAME"}}.
+func indexEnv(node *parse.CommandNode, rooted bool) ([]string, bool) {
+	if len(node.Args) != 3 {
+		return nil, false
+	}
+	if fn, ok := node.Args[0].(*parse.IdentifierNode); !ok || fn.Ident != "index" {
+		return nil, false
+	}
+	key, ok := node.Args[2].(*parse.StringNode)
+	if !ok {
+		return nil, false
+	}
+
+	var ident []string
+	switch arg := node.Args[1].(type) {
+	case *parse.FieldNode:
+		if rooted {
+			ident = arg.Ident
+		}
+	case *parse.VariableNode:
+		if arg.Ident[0] == "$" {
+			ident = arg.Ident[1:]
+		}
+	}
+	if len(ident) != 1 || ident[0] != "Env" {
+		return nil, false
+	}
+	return []string{"Env", key.Text}, true
+}
+
+// isDot returns true if the given pipeline evaluates to exactly dot.
+func isDot(pipe *parse.PipeNode) bool {
+	if pipe == nil || len(pipe.Decl) != 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	_, ok := pipe.Cmds[0].Args[0].(*parse.DotNode)
+	return ok
+}
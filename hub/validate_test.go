@@ -0,0 +1,132 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		title string
+		body  string
+		allow []string
+		error string
+	}{
+		{
+			title: "empty template",
+		},
+		{
+			title: "known keys",
+			body:  "Build {{.Build.Number}} of {{.Git.Branch}} at {{$.Git.SHA}} for {{.Pull.Author}} ({{.Meta.Type}}).",
+		},
+		{
+			title: "arbitrary keys",
+			body:  "{{.Env.ANYTHING}} {{.Event.pull_request.head.ref}} {{range .Labels}}{{.}}{{end}}",
+		},
+		{
+			title: "unknown field",
+			body:  "{{.Bulid.Number}}",
+			error: `template: comment:1:8: unknown field .Bulid`,
+		},
+		{
+			title: "unknown key",
+			body:  "\n\nBuild {{.Build.Nmuber}}",
+			error: `template: comment:3:14: unknown key "Nmuber" in .Build`,
+		},
+		{
+			title: "unknown key in condition",
+			body:  "{{if .Git.Sha}}yes{{end}}",
+			error: `template: comment:1:9: unknown key "Sha" in .Git`,
+		},
+		{
+			title: "unknown key in else branch",
+			body:  "{{with .Pull.Title}}{{.}}{{else}}{{.Pull.Titel}}{{end}}",
+			error: `template: comment:1:40: unknown key "Titel" in .Pull`,
+		},
+		{
+			title: "unknown key from root variable",
+			body:  "{{with .Pull}}{{$.Build.Nmuber}}{{end}}",
+			error: `template: comment:1:17: unknown key "Nmuber" in .Build`,
+		},
+		{
+			title: "relative key inside with",
+			body:  "{{with .Pull}}{{.Title}}{{end}}",
+		},
+		{
+			title: "unknown key in called template",
+			body:  `{{define "footer"}}{{.Build.Nmuber}}{{end}}{{template "footer" .}}`,
+			error: `template: comment:1:27: unknown key "Nmuber" in .Build`,
+		},
+		{
+			title: "relative key in called template",
+			body:  `{{define "footer"}}{{.Title}}{{end}}{{template "footer" .Pull}}`,
+		},
+		{
+			title: "recursive template",
+			body:  `{{define "loop"}}{{.Pull.Title}}{{template "loop" .}}{{end}}{{template "loop" .}}`,
+		},
+		{
+			title: "allowed variable",
+			body:  "{{.Env.CIRCLE_BRANCH}}",
+			allow: []string{"CIRCLE_*"},
+		},
+		{
+			title: "disallowed variable",
+			body:  "{{.Env.HOME}}",
+			allow: []string{"CIRCLE_*"},
+			error: `template: comment:1:6: environment variable "HOME" is not allowed by -env-allow`,
+		},
+		{
+			title: "disallowed variable with index",
+			body:  `{{index .Env "HOME"}}`,
+			allow: []string{"CIRCLE_*"},
+			error: `template: comment:1:13: environment variable "HOME" is not allowed by -env-allow`,
+		},
+		{
+			title: "allowed variable with index",
+			body:  `{{index .Env "CIRCLE_BRANCH"}}`,
+			allow: []string{"CIRCLE_*"},
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			tpl, _, err := NewTemplate([]TemplateFile{{Name: "comment", Body: []byte(test.body)}}, "")
+			assert.Nil(t, err)
+
+			var allowlist *Allowlist
+			if test.allow != nil {
+				allowlist, err = NewAllowlist(test.allow)
+				assert.Nil(t, err)
+			}
+
+			err = Validate(tpl, allowlist)
+			if test.error != "" {
+				assert.EqualError(t, err, test.error)
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestExecuteStrict(t *testing.T) {
+	tpl, cf, err := NewTemplate([]TemplateFile{{Name: "status.txt", Body: []byte("Status:\n\n{{.Env.STAUTS}}")}}, "")
+	assert.Nil(t, err)
+	tpl.Option("missingkey=error")
+
+	env := map[string]string{"STATUS": "passed"}
+	ctx := NewContext(env, env, circleCI{}, nil, &github.Issue{}, "default")
+	_, err = Execute(tpl, ctx, cf)
+
+	var allowlist *Allowlist
+	assert.EqualError(t, allowlist.Explain(err, ctx.Env), `template: status.txt:3:6: environment variable "STAUTS" is not set`)
+}
@@ -0,0 +1,111 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseVar splits a flag value of the form "key=value" into its key and value.
+// The value may be empty.
+func ParseVar(value string) (string, string, error) {
+	pieces := strings.SplitN(value, "=", 2)
+	switch {
+	case len(pieces) != 2:
+		return "", "", fmt.Errorf("malformed variable %q, must be like key=value", value)
+	case !reIdentifier.MatchString(pieces[0]):
+		return "", "", fmt.Errorf("malformed variable name %q", pieces[0])
+	}
+	return pieces[0], pieces[1], nil
+}
+
+// ReadVarFile reads a list of variables from the named dotenv file. Variables
+// are listed one per line, like KEY=value, and may be prefixed with "export".
+// Values may be double quoted, in which case escape sequences like \n are
+// expanded, or single quoted, in which case they are used verbatim. Blank
+// lines, and lines starting with "#", are ignored.
+func ReadVarFile(filename string) (map[string]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Whitespace is allowed around the equals sign.
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		if pieces := strings.SplitN(line, "=", 2); len(pieces) == 2 {
+			line = strings.TrimSpace(pieces[0]) + "=" + pieces[1]
+		}
+
+		key, value, err := ParseVar(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, number, err)
+		}
+		if vars[key], err = dotenvValue(strings.TrimSpace(value)); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, number, err)
+		}
+	}
+
+	return vars, scanner.Err()
+}
+
+// dotenvValue decodes a single, possibly quoted, dotenv value. Unquoted values
+// may be followed by a comment.
+func dotenvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := closingQuote(value, '"')
+		if end < 0 || strings.TrimSpace(stripComment(value[end+1:])) != "" {
+			return "", fmt.Errorf("malformed value %s", value)
+		}
+		return strconv.Unquote(value[:end+1])
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 || strings.TrimSpace(stripComment(value[end+2:])) != "" {
+			return "", fmt.Errorf("malformed value %s", value)
+		}
+		return value[1 : end+1], nil
+	default:
+		return strings.TrimSpace(stripComment(value)), nil
+	}
+}
+
+// stripComment removes any trailing comment from the given unquoted value, or
+// from whatever follows a quoted value. A comment starts with a "#" at the
+// beginning of the value, or after whitespace.
+func stripComment(value string) string {
+	for index := 0; index < len(value); index++ {
+		if value[index] == '#' && (index == 0 || value[index-1] == ' ' || value[index-1] == '\t') {
+			return value[:index]
+		}
+	}
+	return value
+}
+
+// closingQuote returns the index of the quote that closes the quoted string at
+// the start of text, or -1 if there is none.
+func closingQuote(text string, quote byte) int {
+	for index := 1; index < len(text); index++ {
+		switch {
+		case quote == '"' && text[index] == '\\':
+			index++
+		case text[index] == quote:
+			return index
+		}
+	}
+	return -1
+}
@@ -0,0 +1,137 @@
+// Copyright Josh Komoroske. All rights reserved.
+// Use of this source code is governed by the MIT license,
+// a copy of which can be found in the LICENSE.txt file.
+
+package hub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadVarFile(t *testing.T) {
+	tests := []struct {
+		title    string
+		body     string
+		expected map[string]string
+		error    string
+	}{
+		{
+			title:    "empty file",
+			expected: map[string]string{},
+		},
+		{
+			title: "plain values",
+			body:  "# Build results\nSTATUS=passed\n\nexport DURATION = 42s # seconds\nEMPTY=\n",
+			expected: map[string]string{
+				"STATUS":   "passed",
+				"DURATION": "42s",
+				"EMPTY":    "",
+			},
+		},
+		{
+			title: "quoted values",
+			body:  "DOUBLE=\"line 1\\nline 2\" # comment\nSINGLE='no \\n escapes # here'\nEQUALS=a=b\n",
+			expected: map[string]string{
+				"DOUBLE": "line 1\nline 2",
+				"SINGLE": `no \n escapes # here`,
+				"EQUALS": "a=b",
+			},
+		},
+		{
+			title: "comments",
+			body:  "HASH=a#b\nQUOTED=\"a # b\"\nCOMMENT=a # c\nPARTIAL=a 'b # c'\nLEADING=#c\n",
+			expected: map[string]string{
+				"HASH":    "a#b",
+				"QUOTED":  "a # b",
+				"COMMENT": "a",
+				"PARTIAL": "a 'b",
+				"LEADING": "",
+			},
+		},
+		{
+			title: "missing equals",
+			body:  "STATUS=passed\nDURATION\n",
+			error: `vars.env:2: malformed variable "DURATION", must be like key=value`,
+		},
+		{
+			title: "invalid name",
+			body:  "BUILD-STATUS=passed\n",
+			error: `vars.env:1: malformed variable name "BUILD-STATUS"`,
+		},
+		{
+			title: "unterminated quote",
+			body:  "STATUS=\"passed\n",
+			error: `vars.env:1: malformed value "passed`,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "hub-comment")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "vars.env")
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			assert.Nil(t, ioutil.WriteFile(path, []byte(test.body), 0644))
+
+			actual, err := ReadVarFile(path)
+			if test.error != "" {
+				assert.EqualError(t, err, filepath.Join(dir, test.error))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestVar(t *testing.T) {
+	tests := []struct {
+		title    string
+		body     string
+		expected string
+	}{
+		{
+			title:    "field",
+			body:     "{{.Vars.status}}",
+			expected: "passed",
+		},
+		{
+			title:    "function",
+			body:     `{{var "status"}}`,
+			expected: "passed",
+		},
+		{
+			title:    "function with fallback",
+			body:     `{{var "missing" "unknown"}} {{var "status" "unknown"}}`,
+			expected: "unknown passed",
+		},
+		{
+			title: "function without fallback",
+			body:  `{{var "missing"}}`,
+		},
+	}
+
+	for index, test := range tests {
+		name := fmt.Sprintf("%d %s", index+1, test.title)
+		t.Run(name, func(t *testing.T) {
+			tpl, cf, err := NewTemplate([]TemplateFile{{Name: "comment", Body: []byte(test.body)}}, "")
+			assert.Nil(t, err)
+
+			ctx := NewContext(nil, map[string]string{}, circleCI{}, nil, &github.Issue{}, "default")
+			ctx.Vars = map[string]string{"status": "passed"}
+
+			actual, err := Execute(tpl, ctx, cf)
+			assert.Nil(t, err)
+			assert.Equal(t, trim(test.expected), withoutMetadata(t, "default", actual))
+		})
+	}
+}
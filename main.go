@@ -8,9 +8,10 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
+	"strings"
 
+	"github.com/google/go-github/github"
 	"github.com/joshdk/hub-comment/hub"
 )
 
@@ -19,10 +20,49 @@ const (
 	// the token used for authenticating against the GitHub API.
 	githubTokenEnvVar = "GITHUB_TOKEN"
 
-	// pullRequestLinkEnvVar is the name of the environment variable which
-	// holds a link to the current pull request. Injected automatically by
-	// CircleCI.
-	pullRequestLinkEnvVar = "CIRCLE_PULL_REQUEST"
+	// githubAPIURLEnvVar is the name of the environment variable which holds
+	// the endpoint used for communicating with the GitHub API. Injected
+	// automatically by GitHub Actions.
+	githubAPIURLEnvVar = "GITHUB_API_URL"
+
+	// defaultTemplateDir is the directory searched for a template matching
+	// the comment type, when no template is given.
+	defaultTemplateDir = ".hub-comment/templates"
+)
+
+const (
+	// findPRNone disables searching for pull requests matching the current
+	// commit.
+	findPRNone = "none"
+
+	// findPRNewest selects only the most recently opened pull request that
+	// matches the current commit.
+	findPRNewest = "newest"
+
+	// findPRAll selects every open pull request that matches the current
+	// commit.
+	findPRAll = "all"
+)
+
+const (
+	// modeReplace replaces the body of the existing comment, if there is one.
+	modeReplace = "replace"
+
+	// modeNewAndHide always posts a new comment, and then hides every earlier
+	// comment of the same type as outdated.
+	modeNewAndHide = "new-and-hide"
+
+	// modeAppend adds a new timestamped section to the bottom of the
+	// existing comment.
+	modeAppend = "append"
+
+	// modePrepend adds a new timestamped section to the top of the existing
+	// comment.
+	modePrepend = "prepend"
+
+	// modeHistory replaces the body of the existing comment, and folds every
+	// earlier body into a <details> block underneath.
+	modeHistory = "history"
 )
 
 // version can be replaced at build time with a custom version string.
@@ -37,15 +77,146 @@ func main() {
 
 func mainCmd() error {
 	var (
+		// apiURLFlag is a command line flag ("-api-url") that names the GitHub
+		// API endpoint to use. Needed when the API endpoint of a GitHub
+		// Enterprise server can not be inferred from the pull request link.
+		apiURLFlag = flag.String("api-url", "", "GitHub API endpoint to use.")
+
+		// benchBaseFlag is a command line flag ("-bench-base") that names a
+		// file containing the output of "go test -bench" for the base branch,
+		// to compare against the -bench-head file. The name "-" reads from
+		// stdin.
+		benchBaseFlag = flag.String("bench-base", "", `File containing the output of "go test -bench" for the base branch. Use - for stdin.`)
+
+		// benchFailFlag is a command line flag ("-bench-fail") that causes
+		// hub-comment to exit non-zero when any benchmark regressed, after the
+		// comment has been posted.
+		benchFailFlag = flag.Bool("bench-fail", false, "Exit non-zero if any benchmark regressed, after posting the comment.")
+
+		// benchHeadFlag is a command line flag ("-bench-head") that names a
+		// file containing the output of "go test -bench" for the current
+		// commit. The name "-" reads from stdin.
+		benchHeadFlag = flag.String("bench-head", "", `File containing the output of "go test -bench" for the current commit. Use - for stdin.`)
+
+		// benchThresholdFlag is a command line flag ("-bench-threshold") that
+		// holds the percentage by which a benchmark must significantly worsen
+		// to count as a regression.
+		benchThresholdFlag = flag.Float64("bench-threshold", 5, "Percentage by which a benchmark must significantly worsen to count as a regression.")
+
+		// coverageFlag is a command line flag ("-coverage") that names a Go
+		// coverage profile, as written by "go test -coverprofile", to expose
+		// to templates.
+		coverageFlag = flag.String("coverage", "", "Go coverage profile to expose to templates.")
+
+		// dataFlag is a repeatable command line flag ("-data") that names a
+		// structured data file to expose to templates, like
+		// "coverage=coverage.json". JSON, YAML, and TOML files are supported.
+		dataFlag = &stringsFlag{}
+
+		// deleteFlag is a command line flag ("-delete") that deletes the
+		// existing comment for the current -type, instead of posting one. No
+		// template is needed.
+		deleteFlag = flag.Bool("delete", false, "Delete the existing comment for the current -type, instead of posting one.")
+
+		// deleteEmptyFlag is a command line flag ("-delete-empty") that
+		// deletes the existing comment for the current -type, instead of
+		// posting one, whenever the template renders nothing.
+		deleteEmptyFlag = flag.Bool("delete-empty", false, "Delete the existing comment for the current -type when the template renders nothing.")
+
 		// dryRunFlag is a command line flag ("-dry-run") that forces
 		// hub-comment to stop short, skip posting or updating a comment. All
 		// other API actions are still performed.
 		dryRunFlag = flag.Bool("dry-run", false, "Stop before posting or updating comments.")
 
-		// templateFileFlag is a command line flag ("-template-file") that
-		// names a file, the contents of which is used as the posted comment
-		// body.
-		templateFileFlag = flag.String("template-file", "", "File containing comment body to post.")
+		// envAllowFlag is a repeatable command line flag ("-env-allow") that
+		// holds patterns matching the names of environment variables to expose
+		// to templates. When given, all other environment variables are hidden
+		// from templates.
+		envAllowFlag = &stringsFlag{}
+
+		// envAllowFileFlag is a command line flag ("-env-allow-file") that
+		// names a file containing patterns to allow, one per line.
+		envAllowFileFlag = flag.String("env-allow-file", "", "File containing patterns of environment variable names to expose to templates.")
+
+		// findPRFlag is a command line flag ("-find-pr") that selects which
+		// open pull requests to comment on, when no pull request could be
+		// detected from the CI environment. Pull requests are found by
+		// matching against the current commit SHA or branch.
+		findPRFlag = flag.String("find-pr", findPRNewest, fmt.Sprintf("Open pull requests to comment on when none was detected. One of %q, %q, or %q.", findPRNone, findPRNewest, findPRAll))
+
+		// forceFlag is a command line flag ("-force") that causes an existing
+		// comment to be updated even when its content is unchanged.
+		forceFlag = flag.Bool("force", false, "Update an existing comment even if its content is unchanged.")
+
+		// goTestFlag is a repeatable command line flag ("-go-test") that
+		// names a file containing the output of "go test -json" to expose to
+		// templates. The name "-" reads from stdin.
+		goTestFlag = &stringsFlag{}
+
+		// issueFlag is a command line flag ("-issue") that names an issue, or
+		// pull request, to comment on. Overrides any detected pull request.
+		issueFlag = flag.String("issue", "", "Issue to comment on, like owner/repo#123.")
+
+		// junitFlag is a repeatable command line flag ("-junit") that names a
+		// JUnit XML test report, or a glob of reports, to expose to templates.
+		junitFlag = &stringsFlag{}
+
+		// lintFlag is a repeatable command line flag ("-lint") that names a
+		// SARIF or checkstyle XML lint report, or a glob of reports, to expose
+		// to templates.
+		lintFlag = &stringsFlag{}
+
+		// modeFlag is a command line flag ("-mode") that selects how an
+		// existing comment is updated.
+		modeFlag = flag.String("mode", modeReplace, fmt.Sprintf("How to update an existing comment. One of %q, %q, %q, %q, or %q.", modeReplace, modeNewAndHide, modeAppend, modePrepend, modeHistory))
+
+		// numberFlag is a command line flag ("-number") that holds the number
+		// of a pull request or issue to comment on. Must be given along with
+		// the -repo flag.
+		numberFlag = flag.Int("number", 0, "Number of pull request or issue to comment on.")
+
+		// prFlag is a command line flag ("-pr") that names a pull request to
+		// comment on. Overrides any detected pull request.
+		prFlag = flag.String("pr", "", "Pull request to comment on, like owner/repo#123.")
+
+		// redactFlag is a repeatable command line flag ("-redact") that holds
+		// patterns matching the names of additional environment variables to
+		// redact from templates.
+		redactFlag = &stringsFlag{}
+
+		// repoFlag is a command line flag ("-repo") that names a repository
+		// containing a pull request or issue to comment on. Must be given
+		// along with the -number flag.
+		repoFlag = flag.String("repo", "", "Repository to comment on, like owner/repo.")
+
+		// strictFlag is a command line flag ("-strict") that causes template
+		// execution to fail when referencing any missing key, rather than
+		// rendering an empty string. The template is also statically checked
+		// for references to unknown keys before any other work is done.
+		strictFlag = flag.Bool("strict", false, "Fail when the template references a missing key.")
+
+		// templateBuiltinFlag is a command line flag ("-template-builtin")
+		// that names a built-in template to use as the posted comment body.
+		templateBuiltinFlag = flag.String("template-builtin", "", fmt.Sprintf("Built-in template to post. One of %s.", strings.Join(hub.BuiltinTemplateNames(), ", ")))
+
+		// templateDirFlag is a command line flag ("-template-dir") that names
+		// a directory of templates, one per comment type. When no template is
+		// given, the file named after the -type flag, like "default.md", is
+		// used.
+		templateDirFlag = flag.String("template-dir", defaultTemplateDir, "Directory containing a template for each comment type, like <type>.md.")
+
+		// templateEntryFlag is a command line flag ("-template-entry") that
+		// names the template to render, when several template files are
+		// given. May name either a template file, or a template declared with
+		// {{define}}. Defaults to the first template file.
+		templateEntryFlag = flag.String("template-entry", "", "Name of the template to render. Defaults to the first template file.")
+
+		// templateFileFlag is a repeatable command line flag
+		// ("-template-file") that names a file, or a glob of files, the
+		// contents of which is used as the posted comment body. Every file is
+		// available to the others by name, like {{template "footer.md" .}}.
+		// The name "-" reads from stdin.
+		templateFileFlag = &stringsFlag{}
 
 		// templateFlag is a command line flag ("-template") that holds a
 		// string literal used as the posted comment body.
@@ -57,11 +228,35 @@ func mainCmd() error {
 		// comments on a single PR.
 		typeFlag = flag.String("type", "default", "Type of comment to post and edit.")
 
+		// validateFlag is a command line flag ("-validate") that checks the
+		// template for errors, and then exits without commenting. Useful as a
+		// pre-commit check.
+		validateFlag = flag.Bool("validate", false, "Check the template for errors and exit.")
+
+		// varFlag is a repeatable command line flag ("-var") that holds a
+		// variable to expose to templates, like "status=passed". Overrides any
+		// variable of the same name read from a -var-file.
+		varFlag = &stringsFlag{}
+
+		// varFileFlag is a repeatable command line flag ("-var-file") that
+		// names a dotenv file containing variables to expose to templates.
+		varFileFlag = &stringsFlag{}
+
 		// versionFlag is a command line flag ("-version") that will have the
 		// program to print a version string and then exit.
 		versionFlag = flag.Bool("version", false, fmt.Sprintf(`Print the version "%s" and exit.`, version))
 	)
 
+	flag.Var(dataFlag, "data", "Structured data file to expose to templates, like name=path.json. Supports JSON, YAML, and TOML. May be repeated.")
+	flag.Var(envAllowFlag, "env-allow", "Pattern of environment variable names to expose to templates. Hides all others. May be repeated.")
+	flag.Var(goTestFlag, "go-test", `File containing the output of "go test -json" to expose to templates. Use - for stdin. May be repeated.`)
+	flag.Var(junitFlag, "junit", "JUnit XML test report, or glob of reports, to expose to templates. May be repeated.")
+	flag.Var(lintFlag, "lint", "SARIF or checkstyle XML lint report, or glob of reports, to expose to templates. May be repeated.")
+	flag.Var(redactFlag, "redact", fmt.Sprintf("Pattern of environment variable names to redact, in addition to %s. May be repeated.", strings.Join(hub.DefaultRedactPatterns, ", ")))
+	flag.Var(templateFileFlag, "template-file", "File, or glob of files, containing comment body to post. Use - for stdin. May be repeated.")
+	flag.Var(varFlag, "var", "Variable to expose to templates, like key=value. May be repeated.")
+	flag.Var(varFileFlag, "var-file", "Dotenv file containing variables to expose to templates. May be repeated.")
+
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of hub-comment:\n")
 		flag.PrintDefaults()
@@ -73,104 +268,663 @@ func mainCmd() error {
 		return nil
 	}
 
-	token, found := os.LookupEnv(githubTokenEnvVar)
-	if !found {
-		return fmt.Errorf("no GITHUB_TOKEN set in environment")
+	// Stdin can only be read once.
+	if count(*templateFileFlag, hub.StdinFile)+count(*goTestFlag, hub.StdinFile)+count([]string{*benchBaseFlag, *benchHeadFlag}, hub.StdinFile) > 1 {
+		return fmt.Errorf("stdin can only be given once")
 	}
 
-	// If no CIRCLE_PULL_REQUEST is set, print an error and return immediately
-	// but do not fail. This environment variable will not be set on non-pr
-	// branches, or if a build is started before a pr is opened.
-	reference, found := os.LookupEnv(pullRequestLinkEnvVar)
-	if !found {
-		fmt.Fprintln(os.Stderr, "hub-comment: no CIRCLE_PULL_REQUEST set in environment")
+	// Get a template from either the -template flag directly, read from the
+	// -template-file, the -template-builtin, or read from the -template-dir
+	// for the current -type. Test results, coverage, lint findings, and
+	// benchmarks are summarized by default.
+	var fallback string
+	switch {
+	case len(*junitFlag) > 0:
+		fallback = "tests"
+	case len(*goTestFlag) > 0:
+		fallback = "gotest"
+	case *coverageFlag != "":
+		fallback = "coverage"
+	case len(*lintFlag) > 0:
+		fallback = "lint"
+	case *benchHeadFlag != "":
+		fallback = "bench"
+	}
+	templates, err := hub.LoadTemplates(*templateFlag, *templateFileFlag, *templateBuiltinFlag, *templateDirFlag, *typeFlag, fallback, os.Stdin)
+	switch {
+	case *deleteFlag:
+		// Comments are only deleted, never rendered, and so any template is
+		// ignored.
+		templates = []hub.TemplateFile{{Name: "delete"}}
+	case err != nil:
+		return err
+	}
+
+	// Load the list of allowed environment variables, if any patterns were
+	// given with either the -env-allow or -env-allow-file flags.
+	allowlist, err := getAllowlist(*envAllowFlag, *envAllowFileFlag)
+	if err != nil {
+		return err
+	}
+
+	// Parse the template body
+	tpl, cf, err := hub.NewTemplate(templates, *templateEntryFlag)
+	if err != nil {
+		return err
+	}
+
+	// Check the template for references to unknown keys before doing any
+	// other work. References to disallowed environment variables are always
+	// checked.
+	switch {
+	case *strictFlag || *validateFlag:
+		if err := hub.Validate(tpl, allowlist); err != nil {
+			return err
+		}
+	case allowlist != nil:
+		if err := hub.ValidateEnv(tpl, allowlist); err != nil {
+			return err
+		}
+	}
+	cf.Allowlist = allowlist
+
+	if *validateFlag {
+		fmt.Printf("%s: template is valid\n", tpl.Name())
 		return nil
 	}
 
-	owner, repo, number, found := hub.SplitPullRequestReference(reference)
-	if !found {
-		return fmt.Errorf("malformed pull request link")
+	// Referencing a missing key is an error in strict mode.
+	if *strictFlag {
+		tpl.Option("missingkey=error")
 	}
 
-	// Get a template from either the -template flag directly, or read from the
-	// -template-file.
-	template, err := getTemplate(*templateFlag, *templateFileFlag)
+	// Load every structured data file given with the -data flag.
+	data, err := getData(*dataFlag)
 	if err != nil {
 		return err
 	}
 
-	// Parse the template body
-	tpl, cf, err := hub.NewTemplate(template)
+	// Read every test report given with the -junit or -go-test flags.
+	tests, err := getTestReport(*junitFlag, *goTestFlag)
+	if err != nil {
+		return err
+	}
+
+	// Read the coverage profile given with the -coverage flag.
+	coverage := &hub.Coverage{}
+	if *coverageFlag != "" {
+		if coverage, err = hub.ReadCoverProfile(*coverageFlag); err != nil {
+			return err
+		}
+	}
+
+	// Read every lint report given with the -lint flag.
+	lint, err := getLint(*lintFlag)
+	if err != nil {
+		return err
+	}
+
+	// Compare the benchmark results given with the -bench-base and
+	// -bench-head flags.
+	bench, err := getBench(*benchBaseFlag, *benchHeadFlag, *benchThresholdFlag)
 	if err != nil {
 		return err
 	}
 
+	// Fail on benchmark regressions, but only once the comment is posted.
+	var benchErr error
+	if regressions := bench.Regressions(); *benchFailFlag && len(regressions) > 0 {
+		noun := "benchmarks"
+		if len(regressions) == 1 {
+			noun = "benchmark"
+		}
+		benchErr = fmt.Errorf("%d %s regressed by more than %g%%", len(regressions), noun, bench.Threshold)
+	}
+
+	// Collect every variable given with the -var or -var-file flags.
+	vars, err := getVars(*varFlag, *varFileFlag)
+	if err != nil {
+		return err
+	}
+
+	token, found := os.LookupEnv(githubTokenEnvVar)
+	if !found {
+		return fmt.Errorf("no GITHUB_TOKEN set in environment")
+	}
+
+	// Detect which CI system is currently running.
 	var (
-		ctx    = context.Background()
-		client = hub.NewClient(ctx, token)
+		env      = hub.MakeEnv(os.Environ())
+		provider = hub.DetectProvider(env)
 	)
 
-	// Get the current user associated with the given API token.
-	self, err := hub.GetSelf(ctx, client)
+	switch *findPRFlag {
+	case findPRNone, findPRNewest, findPRAll:
+	default:
+		return fmt.Errorf("unknown -find-pr policy %q", *findPRFlag)
+	}
+
+	switch *modeFlag {
+	case modeReplace, modeNewAndHide, modeAppend, modePrepend, modeHistory:
+	default:
+		return fmt.Errorf("unknown -mode %q", *modeFlag)
+	}
+
+	// Get a pull request or issue reference from either the -pr flag, the
+	// -issue flag, or the -repo and -number flags.
+	ref, found, err := getReference(*prFlag, *issueFlag, *repoFlag, *numberFlag)
 	if err != nil {
 		return err
 	}
 
-	// Get information about the given PR number.
-	issue, err := hub.GetIssue(ctx, client, owner, repo, number)
+	// Fallback to the pull request detected from the CI environment.
+	var (
+		commit    hub.Commit
+		searching bool
+	)
+	if !found {
+		link, found := provider.Reference(env)
+		switch {
+		case found:
+			if ref, err = hub.ParseReference(link); err != nil {
+				return err
+			}
+		case *findPRFlag != findPRNone:
+			// Otherwise, search for open pull requests that match the commit
+			// currently being built.
+			commit, searching = hub.DetectCommit(env, provider)
+			ref.Host = commit.Host
+		}
+
+		// If no pull request reference is found, print an error and return
+		// immediately but do not fail. The reference will not be available on
+		// non-pr branches, or if a build is started before a pr is opened.
+		if !found && !searching {
+			fmt.Fprintf(os.Stderr, "hub-comment: no pull request found in %s environment\n", provider.Name())
+			return benchErr
+		}
+	}
+
+	// Use the API endpoint from the -api-url flag, or infer it from the pull
+	// request link. The GITHUB_API_URL environment variable is only used when
+	// it belongs to the same server as the pull request, like on a GitHub
+	// Actions runner for that server. Short references do not name a server,
+	// and so are not checked.
+	apiURL, err := hub.ResolveAPIURL(*apiURLFlag, env[githubAPIURLEnvVar], ref.Host)
 	if err != nil {
 		return err
 	}
 
-	// Get a list of all comments for the given PR number
-	comments, err := hub.GetComments(ctx, client, owner, repo, number)
+	// Redact any secrets from the environment before exposing it to the
+	// template as .Env, and then restrict it to only allowed variables. Build
+	// and Git information is still read from the complete environment.
+	redactor, err := hub.NewRedactor(*redactFlag)
 	if err != nil {
 		return err
 	}
+	redacted := redactor.Redact(env)
+	if allowlist != nil {
+		redacted = allowlist.Filter(redacted)
+	}
 
-	// Select the most recent comment that was authored by the current user, if
-	// one exists.
-	commentID, found := hub.FilterComments(comments, self.GetLogin(), *typeFlag)
+	ctx := context.Background()
 
-	// Build a context object containing the available environment variables.
-	state := hub.NewContext(os.Environ(), issue, *typeFlag)
+	client, gql, err := hub.NewClient(ctx, token, apiURL)
+	if err != nil {
+		return err
+	}
+
+	// Get the current user associated with the given API token.
+	self, err := hub.GetSelf(ctx, client)
+	if err != nil {
+		return err
+	}
 
-	comment, err := hub.Execute(tpl, state, cf)
+	// Load the event payload that triggered the current workflow, if running
+	// inside of GitHub Actions.
+	event, err := hub.LoadEvent(env)
 	if err != nil {
 		return err
 	}
 
-	// Create a new comment or update an existing comment. Save a link to the
-	// resulting comment.
-	var url string
-	if !*dryRunFlag {
-		if found {
-			url, err = hub.UpdateComment(ctx, client, owner, repo, commentID, comment)
-		} else {
-			url, err = hub.PostComment(ctx, client, owner, repo, number, comment)
+	refs := []hub.Reference{ref}
+
+	// Search for open pull requests matching the current commit, and select
+	// either the newest one, or all of them.
+	if searching {
+		if refs, err = hub.FindPullRequests(ctx, client, commit); err != nil {
+			return err
+		}
+
+		if len(refs) == 0 {
+			fmt.Fprintf(os.Stderr, "hub-comment: no open pull request found for %s/%s at %s\n", commit.Owner, commit.Repo, describeCommit(commit))
+			return benchErr
+		}
+
+		if *findPRFlag == findPRNewest {
+			refs = refs[:1]
+		}
+	}
+
+	for index, ref := range refs {
+		if index > 0 {
+			fmt.Println()
+		}
+
+		// Get information about the given PR number.
+		issue, err := hub.GetIssue(ctx, client, ref.Owner, ref.Repo, ref.Number)
+		if err != nil {
+			return err
+		}
+
+		// Delete the existing comment, instead of rendering a new one.
+		if *deleteFlag {
+			if err := deleteComment(ctx, client, ref, self, *typeFlag, *dryRunFlag); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Search the comments for the given PR number, and select the most
+		// recent comment that was authored by the current user, if one exists.
+		existing, err := hub.FindComment(ctx, client, ref.Owner, ref.Repo, ref.Number, self.GetLogin(), *typeFlag)
+		if err != nil {
+			return err
 		}
+		found := existing != nil
+
+		// Build a context object containing the available environment
+		// variables.
+		state := hub.NewContext(env, redacted, provider, event, issue, *typeFlag)
+		state.Meta["Version"] = version
+		state.Bench = bench
+		state.Coverage = coverage.Compare(existing.GetBody())
+		state.Data = data
+		state.Lint = lint
+		state.Tests = tests
+		state.Vars = vars
+
+		comment, err := hub.Execute(tpl, state, cf)
 		if err != nil {
+			return allowlist.Explain(err, env)
+		}
+
+		// Refuse to post a comment that might leak a secret.
+		if err := redactor.Check(comment); err != nil {
 			return err
 		}
+
+		// Delete the existing comment, instead of posting an empty one, when
+		// the template renders nothing.
+		if _, content, _ := hub.ParseComment(comment); *deleteEmptyFlag && content == "" {
+			if err := deleteComment(ctx, client, ref, self, *typeFlag, *dryRunFlag); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Leave an existing comment alone if its content is unchanged, so that
+		// subscribers are not notified about an edit that changed nothing.
+		if found && !*forceFlag && hub.Unchanged(existing.GetBody(), comment) {
+			hub.ReportUnchanged(existing.GetHTMLURL(), self.GetName(), self.GetLogin(), ref.Owner, ref.Repo, ref.Number)
+			continue
+		}
+
+		// Combine the comment with the existing comment, for modes that keep
+		// earlier renders. Every mode is kept within the comment length limit.
+		switch *modeFlag {
+		case modeAppend:
+			comment = hub.AppendSection(existing.GetBody(), comment, false)
+		case modePrepend:
+			comment = hub.AppendSection(existing.GetBody(), comment, true)
+		case modeHistory:
+			comment = hub.AddHistory(existing.GetBody(), comment)
+		default:
+			comment = hub.Truncate(comment)
+		}
+
+		// Create a new comment or update an existing comment. Save a link to
+		// the resulting comment.
+		var (
+			url    string
+			update = found && *modeFlag != modeNewAndHide
+		)
+		if !*dryRunFlag {
+			if update {
+				url, err = hub.UpdateComment(ctx, client, ref.Owner, ref.Repo, existing.GetID(), comment)
+			} else {
+				url, err = hub.PostComment(ctx, client, ref.Owner, ref.Repo, ref.Number, comment)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		// Display a report about the comment that was just posted.
+		hub.Report(comment, url, self.GetName(), self.GetLogin(), ref.Owner, ref.Repo, ref.Number, update, *dryRunFlag)
+
+		// Hide every earlier comment of the same type, now that a newer one
+		// has been posted.
+		if *modeFlag == modeNewAndHide {
+			if err := hideComments(ctx, client, gql, ref, self.GetLogin(), *typeFlag, url, *dryRunFlag); err != nil {
+				return err
+			}
+		}
 	}
 
-	// Display a report about the comment that was just posted.
-	hub.Report(comment, url, self.GetName(), self.GetLogin(), owner, repo, number, found, *dryRunFlag)
+	return benchErr
+}
 
+// deleteComment deletes the most recent comment of the given type by the given
+// user, unless there is none, or unless this is a dry run. Only comments that
+// are explicitly marked with the given type are deleted, so that comments
+// posted by other tools sharing the same user are left alone.
+func deleteComment(ctx context.Context, client *github.Client, ref hub.Reference, self *github.User, typeName string, dryRun bool) error {
+	existing, err := hub.FindTypedComment(ctx, client, ref.Owner, ref.Repo, ref.Number, self.GetLogin(), typeName)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil && !dryRun {
+		if err := hub.DeleteComment(ctx, client, ref.Owner, ref.Repo, existing.GetID()); err != nil {
+			return err
+		}
+	}
+
+	hub.ReportDeleted(existing.GetHTMLURL(), self.GetName(), self.GetLogin(), ref.Owner, ref.Repo, ref.Number, existing != nil, dryRun)
 	return nil
 }
 
-// getTemplate will either return the contents of template verbatim, or return
-// the contents read from templateFile.
-func getTemplate(template string, templateFile string) ([]byte, error) {
+// hideComments minimizes every comment of the given type by the given author,
+// other than the comment with the given link, as outdated. Comments that are
+// already hidden are skipped.
+func hideComments(ctx context.Context, client *github.Client, gql *hub.GraphQLClient, ref hub.Reference, login string, typeName string, url string, dryRun bool) error {
+	comments, err := hub.FindComments(ctx, client, ref.Owner, ref.Repo, ref.Number, login, typeName)
+	if err != nil {
+		return err
+	}
+
+	var outdated []*github.IssueComment
+	for _, comment := range comments {
+		if comment.GetHTMLURL() != url && comment.GetNodeID() != "" {
+			outdated = append(outdated, comment)
+		}
+	}
+
+	ids := make([]string, len(outdated))
+	for index, comment := range outdated {
+		ids[index] = comment.GetNodeID()
+	}
+	minimized, err := hub.MinimizedComments(ctx, gql, ids)
+	if err != nil {
+		return err
+	}
+
+	var urls []string
+	for _, comment := range outdated {
+		if minimized[comment.GetNodeID()] {
+			continue
+		}
+		if !dryRun {
+			if err := hub.MinimizeComment(ctx, gql, comment.GetNodeID()); err != nil {
+				return err
+			}
+		}
+		urls = append(urls, comment.GetHTMLURL())
+	}
+
+	hub.ReportHidden(urls, dryRun)
+	return nil
+}
+
+// describeCommit returns a human readable description of the given commit,
+// naming the SHA and branch.
+func describeCommit(commit hub.Commit) string {
+	switch {
+	case commit.SHA != "" && commit.Branch != "":
+		return fmt.Sprintf("%s (%s)", commit.SHA, commit.Branch)
+	case commit.SHA != "":
+		return commit.SHA
+	default:
+		return commit.Branch
+	}
+}
+
+// getReference returns the pull request or issue named by either pr, issue, or
+// by repo and number together. The returned bool is false if none were given.
+func getReference(pr string, issue string, repo string, number int) (hub.Reference, bool, error) {
+	var (
+		byPR     = pr != ""
+		byIssue  = issue != ""
+		byNumber = repo != "" || number != 0
+	)
+
 	switch {
-	case template == "" && templateFile == "":
-		fallthrough
-	case template != "" && templateFile != "":
-		return nil, fmt.Errorf("a template or a template file must be given")
-	case template != "":
-		return []byte(template), nil
+	case byPR && byIssue, byPR && byNumber, byIssue && byNumber:
+		return hub.Reference{}, false, fmt.Errorf("only one of -pr, -issue, or -repo and -number may be given")
+	case byPR:
+		ref, err := hub.ParseReference(pr)
+		return ref, err == nil, err
+	case byIssue:
+		ref, err := hub.ParseReference(issue)
+		return ref, err == nil, err
+	case byNumber && (repo == "" || number <= 0):
+		return hub.Reference{}, false, fmt.Errorf("-repo and -number must be given together")
+	case byNumber:
+		ref, err := hub.ParseReference(fmt.Sprintf("%s#%d", repo, number))
+		return ref, err == nil, err
 	default:
-		return ioutil.ReadFile(templateFile)
+		return hub.Reference{}, false, nil
+	}
+}
+
+// getData loads each of the given structured data files, which are given like
+// "name=path", and returns them keyed by name.
+func getData(values []string) (map[string]interface{}, error) {
+	data := make(map[string]interface{}, len(values))
+	for _, value := range values {
+		name, path, err := hub.ParseDataFlag(value)
+		if err != nil {
+			return nil, err
+		}
+		if _, found := data[name]; found {
+			return nil, fmt.Errorf("data %q given more than once", name)
+		}
+		if data[name], err = hub.LoadData(path); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// getVars collects the variables read from each of the given dotenv files, in
+// order, followed by each of the given "key=value" variables. Later variables
+// override earlier ones of the same name.
+func getVars(values []string, varFiles []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, varFile := range varFiles {
+		fileVars, err := hub.ReadVarFile(varFile)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fileVars {
+			vars[key] = value
+		}
+	}
+
+	for _, value := range values {
+		key, value, err := hub.ParseVar(value)
+		if err != nil {
+			return nil, err
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// getAllowlist builds an allowlist from the given patterns, along with any
+// patterns read from patternFile. A nil allowlist is returned if no patterns
+// were given at all.
+func getAllowlist(patterns []string, patternFile string) (*hub.Allowlist, error) {
+	if patternFile != "" {
+		filePatterns, err := hub.ReadAllowlistFile(patternFile)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+
+	if len(patterns) == 0 && patternFile == "" {
+		return nil, nil
+	}
+	return hub.NewAllowlist(patterns)
+}
+
+// getTestReport reads every JUnit XML report matched by the given glob
+// patterns, along with every given "go test -json" output file, and combines
+// them into a single report.
+func getTestReport(junitFiles []string, goTestFiles []string) (*hub.TestReport, error) {
+	report := hub.NewTestReport(nil)
+	for _, goTestFile := range goTestFiles {
+		suites, err := readGoTest(goTestFile)
+		if err != nil {
+			return nil, err
+		}
+		report.Add(suites...)
 	}
+
+	for _, pattern := range junitFiles {
+		matches, err := hub.ExpandGlob(pattern, "JUnit")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			suites, err := hub.ReadJUnit(match)
+			if err != nil {
+				return nil, err
+			}
+			report.Add(suites...)
+		}
+	}
+	return report, nil
+}
+
+// getLint reads every SARIF or checkstyle XML report matched by the given glob
+// patterns, and combines their findings. Absolute file paths are made relative
+// to the current directory.
+func getLint(lintFiles []string) (*hub.Lint, error) {
+	root, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	lint := hub.NewLint(nil)
+	for _, pattern := range lintFiles {
+		matches, err := hub.ExpandGlob(pattern, "lint")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			findings, err := hub.ReadLint(match, root)
+			if err != nil {
+				return nil, err
+			}
+			lint.Add(findings...)
+		}
+	}
+	return lint, nil
+}
+
+// getBench reads the output of "go test -bench" from the named base and head
+// files, and compares them. Every benchmark is reported as new if no base file
+// is given.
+func getBench(baseFile string, headFile string, threshold float64) (*hub.Benchmarks, error) {
+	switch {
+	case baseFile != "" && headFile == "":
+		return nil, fmt.Errorf("-bench-base must be given along with -bench-head")
+	case headFile == "":
+		return &hub.Benchmarks{Threshold: threshold}, nil
+	}
+
+	var base []hub.BenchmarkResult
+	if baseFile != "" {
+		results, err := readBench(baseFile)
+		if err != nil {
+			return nil, err
+		}
+		base = results
+	}
+
+	head, err := readBench(headFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return hub.CompareBenchmarks(base, head, threshold), nil
+}
+
+// readBench reads the output of "go test -bench" from the named file, or from
+// stdin if the name is "-".
+func readBench(filename string) ([]hub.BenchmarkResult, error) {
+	if filename == hub.StdinFile {
+		return hub.ReadBench(os.Stdin)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	results, err := hub.ReadBench(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return results, nil
+}
+
+// readGoTest reads the output of "go test -json" from the named file, or from
+// stdin if the name is "-".
+func readGoTest(filename string) ([]hub.TestSuite, error) {
+	if filename == hub.StdinFile {
+		return hub.ReadGoTest(os.Stdin)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	suites, err := hub.ReadGoTest(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return suites, nil
+}
+
+// count returns the number of times value appears in list.
+func count(list []string, value string) int {
+	total := 0
+	for _, item := range list {
+		if item == value {
+			total++
+		}
+	}
+	return total
+}
+
+// stringsFlag is a flag.Value that collects every value given to a repeatable
+// command line flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
 }